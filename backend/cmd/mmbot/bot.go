@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	minPrice = decimal.NewFromFloat(0.01)
+	maxPrice = decimal.NewFromFloat(0.99)
+)
+
+type botConfig struct {
+	MarketIDs    []uint64
+	FairValue    decimal.Decimal // zero means 1/n for an n-outcome market
+	Spread       decimal.Decimal
+	Tick         decimal.Decimal
+	Size         decimal.Decimal
+	MaxInventory decimal.Decimal
+	Interval     time.Duration
+}
+
+// quoteKey identifies one side of the bot's quote on one outcome
+type quoteKey struct {
+	MarketID uint64
+	Outcome  uint8
+	Side     models.OrderSide
+}
+
+// bot keeps a two-sided quote on every outcome of its markets
+type bot struct {
+	api    *apiClient
+	cfg    botConfig
+	quotes map[quoteKey]models.Order
+}
+
+func newBot(api *apiClient, cfg botConfig) *bot {
+	return &bot{
+		api:    api,
+		cfg:    cfg,
+		quotes: make(map[quoteKey]models.Order),
+	}
+}
+
+// Run quotes until the context is cancelled, then pulls all quotes
+func (b *bot) Run(ctx context.Context) error {
+	if err := b.cancelStale(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.step(); err != nil {
+			log.Printf("mmbot: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			b.cancelAll()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// cancelStale removes open orders left on our markets by a previous run
+func (b *bot) cancelStale() error {
+	orders, err := b.api.getUserOrders()
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		if b.tracksMarket(o.MarketID) && isLive(&o) {
+			if err := b.api.cancelOrder(o.ID); err != nil {
+				log.Printf("mmbot: cancel stale order %d: %v", o.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *bot) cancelAll() {
+	for key, q := range b.quotes {
+		if err := b.api.cancelOrder(q.ID); err != nil {
+			log.Printf("mmbot: cancel order %d: %v", q.ID, err)
+		}
+		delete(b.quotes, key)
+	}
+}
+
+func (b *bot) tracksMarket(id uint64) bool {
+	for _, m := range b.cfg.MarketIDs {
+		if m == id {
+			return true
+		}
+	}
+	return false
+}
+
+func isLive(o *models.Order) bool {
+	return o.Status == models.OrderStatusOpen || o.Status == models.OrderStatusPartial
+}
+
+// step refreshes order state and re-quotes any outcome that traded
+func (b *bot) step() error {
+	orders, err := b.api.getUserOrders()
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[uint64]models.Order, len(orders))
+	inventory := make(map[quoteKey]decimal.Decimal)
+	for _, o := range orders {
+		byID[o.ID] = o
+		key := quoteKey{MarketID: o.MarketID, Outcome: o.Outcome}
+		if o.Side == models.OrderSideBuy {
+			inventory[key] = inventory[key].Add(o.FilledQuantity)
+		} else {
+			inventory[key] = inventory[key].Sub(o.FilledQuantity)
+		}
+	}
+
+	for _, marketID := range b.cfg.MarketIDs {
+		market, err := b.api.getMarket(marketID)
+		if err != nil {
+			log.Printf("mmbot: market %d: %v", marketID, err)
+			continue
+		}
+
+		var outcomes []string
+		if err := json.Unmarshal(market.Outcomes, &outcomes); err != nil {
+			log.Printf("mmbot: market %d: corrupted outcomes: %v", marketID, err)
+			continue
+		}
+
+		active := market.Status == models.MarketStatusActive
+		for i := range outcomes {
+			outcome := uint8(i + 1)
+			pos := inventory[quoteKey{MarketID: marketID, Outcome: outcome}]
+			b.refreshOutcome(marketID, outcome, len(outcomes), pos, byID, active)
+		}
+	}
+
+	return nil
+}
+
+// refreshOutcome re-quotes both sides of an outcome after a fill and keeps
+// each side within the inventory limit
+func (b *bot) refreshOutcome(marketID uint64, outcome uint8, numOutcomes int, inventory decimal.Decimal, byID map[uint64]models.Order, active bool) {
+	bidKey := quoteKey{MarketID: marketID, Outcome: outcome, Side: models.OrderSideBuy}
+	askKey := quoteKey{MarketID: marketID, Outcome: outcome, Side: models.OrderSideSell}
+
+	filled := false
+	for _, key := range []quoteKey{bidKey, askKey} {
+		q, ok := b.quotes[key]
+		if !ok {
+			continue
+		}
+		current, found := byID[q.ID]
+		if !found || !isLive(&current) || !current.FilledQuantity.Equal(q.FilledQuantity) {
+			filled = true
+		}
+	}
+
+	canBuy := active && inventory.Add(b.cfg.Size).LessThanOrEqual(b.cfg.MaxInventory)
+	canSell := active && inventory.Sub(b.cfg.Size).GreaterThanOrEqual(b.cfg.MaxInventory.Neg())
+
+	if filled {
+		log.Printf("mmbot: fill on market %d outcome %d, inventory %s, re-quoting", marketID, outcome, inventory)
+		b.pull(bidKey)
+		b.pull(askKey)
+	}
+	if !canBuy {
+		b.pull(bidKey)
+	}
+	if !canSell {
+		b.pull(askKey)
+	}
+
+	fair := b.cfg.FairValue
+	if fair.IsZero() {
+		fair = decimal.NewFromInt(1).Div(decimal.NewFromInt(int64(numOutcomes)))
+	}
+	half := b.cfg.Spread.Div(decimal.NewFromInt(2))
+	bid := clampPrice(floorToTick(fair.Sub(half), b.cfg.Tick))
+	ask := clampPrice(ceilToTick(fair.Add(half), b.cfg.Tick))
+	if bid.GreaterThanOrEqual(ask) {
+		return
+	}
+
+	if _, ok := b.quotes[bidKey]; !ok && canBuy {
+		b.place(bidKey, bid)
+	}
+	if _, ok := b.quotes[askKey]; !ok && canSell {
+		b.place(askKey, ask)
+	}
+}
+
+// pull cancels our quote on one side, if any
+func (b *bot) pull(key quoteKey) {
+	q, ok := b.quotes[key]
+	if !ok {
+		return
+	}
+	if err := b.api.cancelOrder(q.ID); err != nil {
+		// Already filled or cancelled; nothing left to pull
+		log.Printf("mmbot: cancel order %d: %v", q.ID, err)
+	}
+	delete(b.quotes, key)
+}
+
+func (b *bot) place(key quoteKey, price decimal.Decimal) {
+	resp, err := b.api.placeOrder(placeOrderRequest{
+		MarketID: key.MarketID,
+		Outcome:  key.Outcome,
+		Side:     string(key.Side),
+		Price:    price,
+		Quantity: b.cfg.Size,
+	})
+	if err != nil {
+		log.Printf("mmbot: place %s market %d outcome %d @ %s: %v", key.Side, key.MarketID, key.Outcome, price, err)
+		return
+	}
+	if resp.Order != nil {
+		b.quotes[key] = *resp.Order
+	}
+}
+
+func floorToTick(p, tick decimal.Decimal) decimal.Decimal {
+	return p.Div(tick).Floor().Mul(tick)
+}
+
+func ceilToTick(p, tick decimal.Decimal) decimal.Decimal {
+	return p.Div(tick).Ceil().Mul(tick)
+}
+
+func clampPrice(p decimal.Decimal) decimal.Decimal {
+	if p.LessThan(minPrice) {
+		return minPrice
+	}
+	if p.GreaterThan(maxPrice) {
+		return maxPrice
+	}
+	return p
+}
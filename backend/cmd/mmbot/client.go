@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prediction-market/backend/internal/eip712"
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// apiClient talks to the public REST API as a single wallet. Requests are
+// signed with the API key when one is set and with the wallet's key
+// otherwise; orders are always signed with the wallet's key.
+type apiClient struct {
+	baseURL   string
+	signer    *eip712.PrivateKey
+	domain    eip712.Domain
+	apiKey    string
+	apiSecret string
+	http      *http.Client
+}
+
+func newAPIClient(baseURL string, signer *eip712.PrivateKey, domain eip712.Domain) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		signer:  signer,
+		domain:  domain,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// withAPIKey makes the client authenticate requests with an API key
+func (c *apiClient) withAPIKey(key, secret string) *apiClient {
+	c.apiKey = key
+	c.apiSecret = secret
+	return c
+}
+
+// sign adds the authentication headers. The signed path is the request URI
+// as the server sees it, including the /api prefix and query.
+func (c *apiClient) sign(req *http.Request, payload []byte) error {
+	if c.apiKey != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(c.apiSecret))
+		mac.Write([]byte(timestamp + req.Method + req.URL.RequestURI()))
+		mac.Write(payload)
+
+		req.Header.Set("X-API-Key", c.apiKey)
+		req.Header.Set("X-API-Timestamp", timestamp)
+		req.Header.Set("X-API-Signature", hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(raw)
+	timestamp := time.Now().Unix()
+
+	hash := eip712.RequestHash(c.domain, req.Method, req.URL.RequestURI(), payload, timestamp, nonce)
+	signature, err := c.signer.Sign(hash)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Wallet-Address", c.signer.Address())
+	req.Header.Set("X-Signature", signature)
+	req.Header.Set("X-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Nonce", nonce)
+	return nil
+}
+
+type placeOrderRequest struct {
+	MarketID  uint64          `json:"market_id"`
+	Outcome   uint8           `json:"outcome"`
+	Side      string          `json:"side"`
+	Price     decimal.Decimal `json:"price"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	Nonce     string          `json:"nonce"`
+	Expiry    int64           `json:"expiry"`
+	Signature string          `json:"signature"`
+}
+
+func (c *apiClient) signOrder(req *placeOrderRequest) error {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	nonce := new(big.Int).SetBytes(raw)
+
+	price, err := eip712.Units(req.Price)
+	if err != nil {
+		return err
+	}
+	size, err := eip712.Units(req.Quantity)
+	if err != nil {
+		return err
+	}
+	hash, err := eip712.Order{
+		Maker:    c.signer.Address(),
+		MarketID: req.MarketID,
+		Outcome:  req.Outcome,
+		Side:     req.Side,
+		Price:    price,
+		Size:     size,
+		Nonce:    nonce,
+		Expiry:   req.Expiry,
+	}.Hash(c.domain)
+	if err != nil {
+		return err
+	}
+
+	req.Nonce = nonce.String()
+	req.Signature, err = c.signer.Sign(hash)
+	return err
+}
+
+type placeOrderResponse struct {
+	Order  *models.Order  `json:"order"`
+	Trades []models.Trade `json:"trades"`
+}
+
+// do sends a request and decodes a JSON response into out (if non-nil)
+func (c *apiClient) do(method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.sign(req, payload); err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, apiErr.Error)
+		}
+		return fmt.Errorf("%s %s: %d", method, path, resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (c *apiClient) getMarket(id uint64) (*models.Market, error) {
+	var market models.Market
+	if err := c.do(http.MethodGet, fmt.Sprintf("/markets/%d", id), nil, &market); err != nil {
+		return nil, err
+	}
+	return &market, nil
+}
+
+// getUserOrders returns all of the bot's orders, following next_cursor
+// through every page
+func (c *apiClient) getUserOrders() ([]models.Order, error) {
+	var orders []models.Order
+	cursor := ""
+	for {
+		var page struct {
+			Data       []models.Order `json:"data"`
+			NextCursor string         `json:"next_cursor"`
+		}
+		path := "/user/orders?limit=500"
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+		if err := c.do(http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		orders = append(orders, page.Data...)
+		if page.NextCursor == "" {
+			return orders, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// placeOrder signs the order with a random nonce and no expiry and places it
+func (c *apiClient) placeOrder(req placeOrderRequest) (*placeOrderResponse, error) {
+	if err := c.signOrder(&req); err != nil {
+		return nil, err
+	}
+
+	var resp placeOrderResponse
+	if err := c.do(http.MethodPost, "/orders", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *apiClient) cancelOrder(id uint64) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/orders/%d", id), nil, nil)
+}
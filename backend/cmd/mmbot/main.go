@@ -0,0 +1,92 @@
+// Command mmbot is a reference market maker that quotes a fixed spread
+// around a fair value on every outcome of the selected markets through the
+// public REST API.
+//
+//	go run ./cmd/mmbot -api http://localhost:8080/api -key 0x... -markets 1,2
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prediction-market/backend/internal/eip712"
+	"github.com/shopspring/decimal"
+)
+
+func main() {
+	apiURL := flag.String("api", "http://localhost:8080/api", "backend API base URL")
+	key := flag.String("key", os.Getenv("MMBOT_PRIVATE_KEY"), "hex private key of the wallet to trade as")
+	apiKey := flag.String("api-key", os.Getenv("MMBOT_API_KEY"), "API key to authenticate requests with (optional)")
+	apiSecret := flag.String("api-secret", os.Getenv("MMBOT_API_SECRET"), "secret of -api-key")
+	chainID := flag.Int64("chain-id", 11155111, "chain ID of the backend's EIP-712 domain")
+	markets := flag.String("markets", "", "comma-separated market IDs to quote")
+	fair := flag.Float64("fair", 0, "fair value for every outcome (0 = 1/number of outcomes)")
+	spread := flag.Float64("spread", 0.04, "total width between bid and ask")
+	tick := flag.Float64("tick", 0.01, "price tick to round quotes to")
+	size := flag.Float64("size", 10, "quantity per quote")
+	maxInventory := flag.Float64("max-inventory", 100, "maximum absolute net position per outcome")
+	interval := flag.Duration("interval", 5*time.Second, "polling interval for fills")
+	flag.Parse()
+
+	if *key == "" {
+		log.Fatal("mmbot: -key is required")
+	}
+	signer, err := eip712.ParsePrivateKey(*key)
+	if err != nil {
+		log.Fatal("mmbot: invalid -key: ", err)
+	}
+	domain := eip712.Domain{Name: "PredictionMarket", Version: "1", ChainID: *chainID}
+
+	marketIDs, err := parseMarketIDs(*markets)
+	if err != nil || len(marketIDs) == 0 {
+		log.Fatal("mmbot: -markets must be a comma-separated list of market IDs")
+	}
+
+	cfg := botConfig{
+		MarketIDs:    marketIDs,
+		FairValue:    decimal.NewFromFloat(*fair),
+		Spread:       decimal.NewFromFloat(*spread),
+		Tick:         decimal.NewFromFloat(*tick),
+		Size:         decimal.NewFromFloat(*size),
+		MaxInventory: decimal.NewFromFloat(*maxInventory),
+		Interval:     *interval,
+	}
+	if cfg.Tick.LessThanOrEqual(decimal.Zero) || cfg.Size.LessThanOrEqual(decimal.Zero) {
+		log.Fatal("mmbot: -tick and -size must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("mmbot: quoting markets %v as %s against %s", marketIDs, signer.Address(), *apiURL)
+	client := newAPIClient(*apiURL, signer, domain)
+	if *apiKey != "" {
+		client.withAPIKey(*apiKey, *apiSecret)
+	}
+	if err := newBot(client, cfg).Run(ctx); err != nil {
+		log.Fatal("mmbot: ", err)
+	}
+}
+
+func parseMarketIDs(s string) ([]uint64, error) {
+	var ids []uint64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -1,16 +1,32 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/prediction-market/backend/internal/config"
+	"github.com/prediction-market/backend/internal/eip712"
 	"github.com/prediction-market/backend/internal/handlers"
 	"github.com/prediction-market/backend/internal/middleware"
 	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/admins"
+	"github.com/prediction-market/backend/internal/services/amm"
+	"github.com/prediction-market/backend/internal/services/apikeys"
+	"github.com/prediction-market/backend/internal/services/candles"
+	"github.com/prediction-market/backend/internal/services/feed"
+	"github.com/prediction-market/backend/internal/services/idempotency"
+	"github.com/prediction-market/backend/internal/services/multisig"
+	"github.com/prediction-market/backend/internal/services/oracle"
 	"github.com/prediction-market/backend/internal/services/orderbook"
+	"github.com/prediction-market/backend/internal/services/resolution"
+	"github.com/prediction-market/backend/internal/services/rewards"
+	"github.com/prediction-market/backend/internal/services/risk"
+	"github.com/prediction-market/backend/internal/services/session"
+	"github.com/prediction-market/backend/internal/services/stats"
 )
 
 func main() {
@@ -27,52 +43,206 @@ func main() {
 
 	obm := orderbook.NewOrderBookManager()
 
-	marketHandler := handlers.NewMarketHandler(db)
-	orderHandler := handlers.NewOrderHandler(db, obm)
-	adminHandler := handlers.NewAdminHandler(db)
+	hub := feed.NewHub()
+	obm.SetListener(hub.PublishBook)
 
-	r := gin.Default()
+	rewardService := rewards.NewService(db, obm, cfg.RewardsSampleInterval, cfg.RewardsEpochDuration, cfg.RewardsMaxSpread, cfg.TreasuryAddress)
+	rewardService.SetListener(hub)
+	go rewardService.Run(context.Background())
 
-	// CORS
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-Wallet-Address")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
+	ammService := amm.NewService(db, cfg.AMMAddress, cfg.TreasuryAddress)
+
+	if err := candles.Backfill(db); err != nil {
+		log.Fatal("Failed to backfill candles:", err)
+	}
+
+	statsService := stats.NewService(db, obm)
+	if err := statsService.Load(); err != nil {
+		log.Fatal("Failed to load market statistics:", err)
+	}
+
+	resolutionService := resolution.NewService(db, obm, cfg.DisputeWindow, cfg.DisputeBond, cfg.TreasuryAddress)
+	resolutionService.SetListener(hub)
+	go resolutionService.Run(context.Background(), time.Minute)
+
+	oraclePolicy := oracle.Policy{DevSources: cfg.OracleDevSources, FileDir: cfg.OracleFileDir}
+	oracleWorker := oracle.NewWorker(db, resolutionService, cfg.OracleTimeout, oraclePolicy)
+	go oracleWorker.Run(context.Background(), cfg.OraclePollInterval)
+
+	adminSigners, err := multisig.ParseSigners(cfg.AdminSigners)
+	if err != nil {
+		log.Fatal("Invalid ADMIN_SIGNERS:", err)
+	}
+	if len(adminSigners) > 0 && (cfg.AdminApprovalThreshold < 1 || cfg.AdminApprovalThreshold > len(adminSigners)) {
+		log.Fatal("ADMIN_APPROVAL_THRESHOLD must be between 1 and the number of ADMIN_SIGNERS")
+	}
+	multisigService := multisig.NewService(db, adminSigners, cfg.AdminApprovalThreshold)
+
+	// EIP-712 domain of signed requests and orders
+	walletDomain := eip712.Domain{Name: cfg.EIP712Name, Version: cfg.EIP712Version, ChainID: cfg.ChainID}
+
+	riskService := risk.NewService(db, risk.Limits{
+		MaxOpenOrders:     cfg.RiskMaxOpenOrders,
+		MaxPosition:       cfg.RiskMaxPosition,
+		MaxMarketNotional: cfg.RiskMaxMarketNotional,
+		MaxDailyVolume:    cfg.RiskMaxDailyVolume,
 	})
 
+	idempotencyService := idempotency.NewService(db, cfg.IdempotencyKeyTTL)
+	go idempotencyService.Run(context.Background(), time.Hour)
+
+	marketHandler := handlers.NewMarketHandler(db, ammService, statsService)
+	orderHandler := handlers.NewOrderHandler(db, obm, ammService, statsService, hub, riskService, walletDomain)
+	adminHandler := handlers.NewAdminHandler(db, ammService, resolutionService, multisigService, oraclePolicy)
+	rewardHandler := handlers.NewRewardHandler(db)
+	streamHandler := handlers.NewStreamHandler(db, obm, hub)
+	disputeHandler := handlers.NewDisputeHandler(db, resolutionService)
+	riskHandler := handlers.NewRiskHandler(riskService)
+	sessionService := session.NewService(db, cfg.JWTSecret, cfg.SIWEDomain, cfg.ChainID, cfg.SIWENonceTTL, cfg.SessionTTL, cfg.RefreshTokenTTL)
+	authHandler := handlers.NewAuthHandler(sessionService)
+	apiKeyService, err := apikeys.NewService(db, cfg.APIKeyEncryptionKey)
+	if err != nil {
+		log.Fatal("Failed to initialize api keys:", err)
+	}
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	adminService := admins.NewService(db, cfg.JWTSecret, cfg.AdminTokenTTL)
+	if cfg.AdminBootstrapUser != "" {
+		created, err := adminService.Bootstrap(cfg.AdminBootstrapUser, cfg.AdminBootstrapPassword)
+		if err != nil {
+			log.Fatal("Failed to create bootstrap admin:", err)
+		}
+		if created {
+			log.Printf("Created super admin %q", cfg.AdminBootstrapUser)
+		}
+	}
+	adminAccountHandler := handlers.NewAdminAccountHandler(adminService)
+
+	r := gin.Default()
+	// Only believe X-Forwarded-For from our own proxies; API key IP
+	// allowlists and rate limits key on the client IP
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
+	// Cross-origin policies: public reads from anywhere, user and admin
+	// routes only from the configured frontends
+	rateLimitHeaders := []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"}
+	publicCORS := middleware.CORSPolicy{
+		AllowedOrigins: cfg.CORSPublicOrigins,
+		ExposedHeaders: rateLimitHeaders,
+	}
+	userCORS := middleware.CORSPolicy{
+		AllowedOrigins:   cfg.CORSUserOrigins,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Origin", "Content-Type", "Authorization", "X-Wallet-Address", "X-Signature", "X-Timestamp", "X-Nonce", "X-API-Key", "X-API-Timestamp", "X-API-Signature", "Idempotency-Key"},
+		ExposedHeaders:   append(rateLimitHeaders, "Idempotent-Replayed"),
+		MaxAge:           cfg.CORSMaxAge,
+	}
+	adminCORS := middleware.CORSPolicy{
+		AllowedOrigins:   cfg.CORSAdminOrigins,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Origin", "Content-Type", "Authorization"},
+		MaxAge:           cfg.CORSMaxAge,
+	}
+
+	r.Use(middleware.SecurityHeaders(cfg.HSTSMaxAge))
+	// Render errors that handlers record with c.Error
+	r.Use(middleware.Errors())
+	// Browsers preflight only requests with custom headers or bodies, which
+	// on /api means authenticated ones
+	r.Use(middleware.Preflight(map[string]middleware.CORSPolicy{
+		"/api":       userCORS,
+		"/api/admin": adminCORS,
+	}))
+
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Token-bucket rate limits per API key, wallet or IP
+	rateLimits := middleware.NewMemoryRateLimitStore()
+	orderLimit := middleware.RateLimit("orders", middleware.Limit{Rate: cfg.OrderRateLimit, Burst: cfg.OrderRateBurst}, rateLimits)
+	cancelLimit := middleware.RateLimit("cancels", middleware.Limit{Rate: cfg.CancelRateLimit, Burst: cfg.CancelRateBurst}, rateLimits)
+	readLimit := middleware.RateLimit("reads", middleware.Limit{Rate: cfg.ReadRateLimit, Burst: cfg.ReadRateBurst}, rateLimits)
+	authLimit := middleware.RateLimit("auth", middleware.Limit{Rate: cfg.AuthRateLimit, Burst: cfg.AuthRateBurst}, rateLimits)
+	userIPLimit := middleware.RateLimitByIP("user_ip", middleware.Limit{Rate: cfg.UserIPRateLimit, Burst: cfg.UserIPRateBurst}, rateLimits)
+
 	// Public API
 	api := r.Group("/api")
+	api.Use(middleware.CORS(publicCORS), readLimit)
 	{
 		api.GET("/markets", marketHandler.List)
+		api.GET("/categories", marketHandler.ListCategories)
 		api.GET("/markets/:id", marketHandler.Get)
 		api.GET("/markets/:id/trades", marketHandler.GetTrades)
 		api.GET("/markets/:id/orderbook", orderHandler.GetOrderBook)
+		api.GET("/markets/:id/amm", marketHandler.GetAMMQuote)
+		api.GET("/markets/:id/candles", marketHandler.GetCandles)
+		api.GET("/markets/:id/stream", streamHandler.ServeSSE)
+		api.GET("/ws", streamHandler.ServeWS)
+		api.GET("/markets/:id/disputes", disputeHandler.ListDisputes)
+	}
+
+	// Sign-in endpoints
+	auth := r.Group("/api")
+	auth.Use(middleware.CORS(userCORS), middleware.NoStore(), authLimit)
+	{
+		auth.GET("/auth/nonce", authHandler.GetNonce)
+		auth.POST("/auth/siwe", authHandler.SignIn)
+		auth.POST("/auth/refresh", authHandler.Refresh)
+		auth.POST("/auth/logout", authHandler.Logout)
 	}
 
-	// User API (requires wallet)
+	adminLogin := r.Group("/api/admin")
+	adminLogin.Use(middleware.CORS(adminCORS), middleware.NoStore(), authLimit)
+	{
+		adminLogin.POST("/login", adminAccountHandler.Login)
+	}
+
+	// User API (requires a session token, an API key or a wallet-signed
+	// request). API keys are limited to the scope of each route.
+	nonces := middleware.NewMemoryNonceStore()
+	walletAuth := middleware.WalletAuth(walletDomain, cfg.AuthMaxSkew, nonces)
+	apiKeyAuth := middleware.APIKeyAuth(apiKeyService, cfg.AuthMaxSkew, nonces)
+	idempotent := middleware.Idempotency(idempotencyService)
 	user := r.Group("/api")
-	user.Use(middleware.WalletAuth())
+	user.Use(middleware.CORS(userCORS), middleware.NoStore(), userIPLimit, middleware.UserAuth(cfg.JWTSecret, apiKeyAuth, walletAuth))
 	{
-		user.POST("/orders", orderHandler.PlaceOrder)
-		user.DELETE("/orders/:id", orderHandler.CancelOrder)
-		user.GET("/user/orders", orderHandler.GetUserOrders)
+		user.POST("/orders", orderLimit, middleware.RequireScope(apikeys.ScopeTrade), idempotent, orderHandler.PlaceOrder)
+		user.DELETE("/orders/:id", cancelLimit, middleware.RequireScope(apikeys.ScopeCancel), idempotent, orderHandler.CancelOrder)
+		user.DELETE("/orders/client/:client_order_id", cancelLimit, middleware.RequireScope(apikeys.ScopeCancel), idempotent, orderHandler.CancelOrderByClientID)
+		user.GET("/user/orders", readLimit, middleware.RequireScope(apikeys.ScopeRead), orderHandler.GetUserOrders)
+		user.GET("/user/rewards", readLimit, middleware.RequireScope(apikeys.ScopeRead), rewardHandler.GetUserRewards)
+		user.GET("/user/ws", readLimit, middleware.RequireScope(apikeys.ScopeRead), streamHandler.ServeUserWS)
+		user.POST("/markets/:id/disputes", orderLimit, middleware.RequireScope(apikeys.ScopeTrade), disputeHandler.CreateDispute)
+
+		// Key management needs a Sign-In with Ethereum session
+		user.POST("/user/api-keys", authLimit, middleware.RequireSession(), apiKeyHandler.CreateAPIKey)
+		user.GET("/user/api-keys", readLimit, middleware.RequireSession(), apiKeyHandler.ListAPIKeys)
+		user.DELETE("/user/api-keys/:id", authLimit, middleware.RequireSession(), apiKeyHandler.RevokeAPIKey)
 	}
 
-	// Admin API (requires JWT)
+	// Admin API (requires an admin access token; handlers check roles)
 	admin := r.Group("/api/admin")
-	admin.Use(middleware.JWTAuth(cfg.JWTSecret))
+	admin.Use(middleware.CORS(adminCORS), middleware.NoStore(), middleware.JWTAuth(adminService))
 	{
 		admin.POST("/markets", adminHandler.CreateMarket)
+		admin.PATCH("/markets/:id", adminHandler.UpdateMarket)
 		admin.POST("/markets/:id/resolve", adminHandler.ResolveMarket)
+		admin.POST("/markets/:id/finalize", adminHandler.FinalizeMarket)
+		admin.POST("/markets/:id/cancel", adminHandler.CancelMarket)
+		admin.GET("/markets/:id/approvals", adminHandler.ListApprovals)
+		admin.GET("/trades/:id/settlement", adminHandler.GetTradeSettlement)
+		admin.PUT("/markets/:id/rewards", adminHandler.SetRewardPool)
+		admin.GET("/users/:address/risk-limits", riskHandler.GetRiskLimits)
+		admin.PUT("/users/:address/risk-limits", riskHandler.SetRiskLimits)
+		admin.DELETE("/users/:address/risk-limits", riskHandler.ClearRiskLimits)
+		admin.GET("/accounts", adminAccountHandler.ListAccounts)
+		admin.POST("/accounts", adminAccountHandler.CreateAccount)
+		admin.PATCH("/accounts/:id", adminAccountHandler.UpdateAccount)
 	}
 
 	port := os.Getenv("PORT")
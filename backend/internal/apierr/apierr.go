@@ -0,0 +1,143 @@
+// Package apierr defines the errors handlers report to clients: a stable,
+// machine-readable code, the HTTP status it maps to and a message that is
+// safe to show. Handlers record them with c.Error and middleware.Errors
+// renders them as {"error": message, "code": code}.
+package apierr
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code identifies an error for clients. Codes are part of the API and must
+// not change once published.
+type Code string
+
+const (
+	// Generic
+	CodeInvalidRequest  Code = "INVALID_REQUEST"
+	CodeUnauthorized    Code = "UNAUTHORIZED"
+	CodeForbidden       Code = "FORBIDDEN"
+	CodeNotFound        Code = "NOT_FOUND"
+	CodeConflict        Code = "CONFLICT"
+	CodeRateLimited     Code = "RATE_LIMITED"
+	CodeRequestTooLarge Code = "REQUEST_TOO_LARGE"
+	CodeInternal        Code = "INTERNAL"
+
+	// Idempotency keys
+	CodeIdempotencyKeyInProgress Code = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	CodeIdempotencyKeyReused     Code = "IDEMPOTENCY_KEY_REUSED"
+
+	// Markets
+	CodeMarketNotFound     Code = "MARKET_NOT_FOUND"
+	CodeMarketClosed       Code = "MARKET_CLOSED"
+	CodeInvalidMarketState Code = "INVALID_MARKET_STATE"
+	CodeInvalidResolution  Code = "INVALID_RESOLUTION"
+	CodeAlreadyDisputed    Code = "ALREADY_DISPUTED"
+
+	// Orders and trades
+	CodeOrderNotFound       Code = "ORDER_NOT_FOUND"
+	CodeTradeNotFound       Code = "TRADE_NOT_FOUND"
+	CodePriceOutOfRange     Code = "PRICE_OUT_OF_RANGE"
+	CodeInvalidSignature    Code = "INVALID_SIGNATURE"
+	CodeOrderExpired        Code = "ORDER_EXPIRED"
+	CodeNonceUsed           Code = "NONCE_ALREADY_USED"
+	CodeClientOrderIDUsed   Code = "CLIENT_ORDER_ID_USED"
+	CodeInsufficientBalance Code = "INSUFFICIENT_BALANCE"
+	CodeRiskLimitExceeded   Code = "RISK_LIMIT_EXCEEDED"
+	CodeOrderNotCancellable Code = "ORDER_NOT_CANCELLABLE"
+
+	// Admin
+	CodeRoleRequired     Code = "ROLE_REQUIRED"
+	CodeApprovalRejected Code = "APPROVAL_REJECTED"
+	CodeAlreadyApproved  Code = "ALREADY_APPROVED"
+)
+
+// statuses maps each code to its HTTP status
+var statuses = map[Code]int{
+	CodeInvalidRequest:  http.StatusBadRequest,
+	CodeUnauthorized:    http.StatusUnauthorized,
+	CodeForbidden:       http.StatusForbidden,
+	CodeNotFound:        http.StatusNotFound,
+	CodeConflict:        http.StatusConflict,
+	CodeRateLimited:     http.StatusTooManyRequests,
+	CodeRequestTooLarge: http.StatusRequestEntityTooLarge,
+	CodeInternal:        http.StatusInternalServerError,
+
+	CodeIdempotencyKeyInProgress: http.StatusConflict,
+	CodeIdempotencyKeyReused:     http.StatusUnprocessableEntity,
+
+	CodeMarketNotFound:     http.StatusNotFound,
+	CodeMarketClosed:       http.StatusBadRequest,
+	CodeInvalidMarketState: http.StatusBadRequest,
+	CodeInvalidResolution:  http.StatusBadRequest,
+	CodeAlreadyDisputed:    http.StatusConflict,
+
+	CodeOrderNotFound:       http.StatusNotFound,
+	CodeTradeNotFound:       http.StatusNotFound,
+	CodePriceOutOfRange:     http.StatusBadRequest,
+	CodeInvalidSignature:    http.StatusBadRequest,
+	CodeOrderExpired:        http.StatusBadRequest,
+	CodeNonceUsed:           http.StatusConflict,
+	CodeClientOrderIDUsed:   http.StatusConflict,
+	CodeInsufficientBalance: http.StatusBadRequest,
+	CodeRiskLimitExceeded:   http.StatusForbidden,
+	CodeOrderNotCancellable: http.StatusBadRequest,
+
+	CodeRoleRequired:     http.StatusForbidden,
+	CodeApprovalRejected: http.StatusForbidden,
+	CodeAlreadyApproved:  http.StatusConflict,
+}
+
+// Error is an error reported to the client
+type Error struct {
+	Code    Code
+	Message string
+	// Err is the underlying cause, kept for the request log and never
+	// sent to the client
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status is the HTTP status the error is rendered with
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// New creates an error with a message for the client
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Invalid reports a malformed request, such as a body that fails to bind.
+// The message of err is shown to the client.
+func Invalid(err error) *Error {
+	return &Error{Code: CodeInvalidRequest, Message: err.Error()}
+}
+
+// Internal hides err from the client behind a generic message
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// From returns err as an *Error. Errors that are not one are internal.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
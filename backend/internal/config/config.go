@@ -3,6 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
@@ -12,6 +17,98 @@ type Config struct {
 	EthRPCURL       string
 	ContractAddress string
 	OperatorKey     string
+
+	// Liquidity rewards
+	RewardsSampleInterval time.Duration
+	RewardsEpochDuration  time.Duration
+	RewardsMaxSpread      decimal.Decimal
+
+	// Platform account the LMSR market maker trades as
+	AMMAddress string
+
+	// Platform account that funds AMM pools and maker rewards and
+	// receives forfeited dispute bonds
+	TreasuryAddress string
+
+	// Resolution challenge period and the bond required to dispute
+	DisputeWindow time.Duration
+	DisputeBond   decimal.Decimal
+
+	// Oracle polling for markets bound to a resolution source
+	OraclePollInterval time.Duration
+	OracleTimeout      time.Duration
+	// Allow the file and static oracle sources, for local development only.
+	// File sources read from OracleFileDir.
+	OracleDevSources bool
+	OracleFileDir    string
+
+	// M-of-N approval for resolving and cancelling markets. Signers are
+	// "name:hex-ed25519-pubkey" pairs; empty disables approvals.
+	AdminSigners           string
+	AdminApprovalThreshold int
+
+	// EIP-712 domain that wallets sign API requests in, and how far a
+	// request timestamp may drift from server time
+	ChainID       int64
+	EIP712Name    string
+	EIP712Version string
+	AuthMaxSkew   time.Duration
+
+	// Sign-In with Ethereum: the domain sign-in messages must name, and
+	// the lifetimes of nonces, access tokens and refresh tokens
+	SIWEDomain      string
+	SIWENonceTTL    time.Duration
+	SessionTTL      time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Key that API key secrets are encrypted with at rest
+	APIKeyEncryptionKey string
+
+	// Admin access token lifetime, and the super admin created on first
+	// start when there are no admin accounts
+	AdminTokenTTL          time.Duration
+	AdminBootstrapUser     string
+	AdminBootstrapPassword string
+
+	// Token-bucket rate limits per client: requests per second and burst
+	OrderRateLimit  float64
+	OrderRateBurst  int
+	CancelRateLimit float64
+	CancelRateBurst int
+	ReadRateLimit   float64
+	ReadRateBurst   int
+	AuthRateLimit   float64
+	AuthRateBurst   int
+	// Per IP across all user routes, counted before authentication
+	UserIPRateLimit float64
+	UserIPRateBurst int
+
+	// Default per-user risk limits; admins can override them per user.
+	// Zero disables a limit.
+	RiskMaxOpenOrders     int
+	RiskMaxPosition       decimal.Decimal
+	RiskMaxMarketNotional decimal.Decimal
+	RiskMaxDailyVolume    decimal.Decimal
+
+	// How long the response to a request with an Idempotency-Key is kept
+	// for retries
+	IdempotencyKeyTTL time.Duration
+
+	// Origins browsers may call each group of routes from ("*" for any),
+	// whether user and admin routes accept credentials, how long browsers
+	// cache preflights, and the Strict-Transport-Security max-age (0 to
+	// leave it out when not serving over TLS)
+	CORSPublicOrigins    []string
+	CORSUserOrigins      []string
+	CORSAdminOrigins     []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+	HSTSMaxAge           time.Duration
+
+	// Reverse proxies whose X-Forwarded-For is believed when finding a
+	// client's IP (addresses or CIDRs). Empty uses the connection's
+	// address.
+	TrustedProxies []string
 }
 
 func Load() *Config {
@@ -27,6 +124,8 @@ func Load() *Config {
 			dbHost, dbPort, dbUser, dbPassword, dbName)
 	}
 
+	userOrigins := getEnvList("CORS_ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"})
+
 	return &Config{
 		Port:            getEnv("SERVER_PORT", getEnv("PORT", "8080")),
 		DatabaseURL:     dbURL,
@@ -34,6 +133,68 @@ func Load() *Config {
 		EthRPCURL:       getEnv("ETH_RPC_URL", ""),
 		ContractAddress: getEnv("CONTRACT_ADDRESS", ""),
 		OperatorKey:     getEnv("OPERATOR_PRIVATE_KEY", getEnv("OPERATOR_KEY", "")),
+
+		RewardsSampleInterval: getEnvDuration("REWARDS_SAMPLE_INTERVAL", time.Minute),
+		RewardsEpochDuration:  getEnvDuration("REWARDS_EPOCH_DURATION", 24*time.Hour),
+		RewardsMaxSpread:      getEnvDecimal("REWARDS_MAX_SPREAD", decimal.NewFromFloat(0.05)),
+
+		AMMAddress: getEnv("AMM_ADDRESS", "amm"),
+
+		TreasuryAddress: getEnv("TREASURY_ADDRESS", "treasury"),
+
+		DisputeWindow: getEnvDuration("DISPUTE_WINDOW", 24*time.Hour),
+		DisputeBond:   getEnvDecimal("DISPUTE_BOND", decimal.NewFromInt(100)),
+
+		OraclePollInterval: getEnvDuration("ORACLE_POLL_INTERVAL", time.Minute),
+		OracleTimeout:      getEnvDuration("ORACLE_TIMEOUT", 10*time.Second),
+		OracleDevSources:   getEnvBool("ORACLE_DEV_SOURCES", false),
+		OracleFileDir:      getEnv("ORACLE_FILE_DIR", ""),
+
+		AdminSigners:           getEnv("ADMIN_SIGNERS", ""),
+		AdminApprovalThreshold: getEnvInt("ADMIN_APPROVAL_THRESHOLD", 2),
+
+		ChainID:       int64(getEnvInt("CHAIN_ID", 11155111)),
+		EIP712Name:    getEnv("EIP712_NAME", "PredictionMarket"),
+		EIP712Version: getEnv("EIP712_VERSION", "1"),
+		AuthMaxSkew:   getEnvDuration("AUTH_MAX_SKEW", 5*time.Minute),
+
+		SIWEDomain:      getEnv("SIWE_DOMAIN", "localhost:5173"),
+		SIWENonceTTL:    getEnvDuration("SIWE_NONCE_TTL", 10*time.Minute),
+		SessionTTL:      getEnvDuration("SESSION_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		APIKeyEncryptionKey: getEnv("API_KEY_ENCRYPTION_KEY", "dev-api-key-encryption-change-in-production"),
+
+		AdminTokenTTL:          getEnvDuration("ADMIN_TOKEN_TTL", time.Hour),
+		AdminBootstrapUser:     getEnv("ADMIN_USERNAME", ""),
+		AdminBootstrapPassword: getEnv("ADMIN_PASSWORD", ""),
+
+		OrderRateLimit:  getEnvFloat("RATE_LIMIT_ORDERS", 5),
+		OrderRateBurst:  getEnvInt("RATE_LIMIT_ORDERS_BURST", 20),
+		CancelRateLimit: getEnvFloat("RATE_LIMIT_CANCELS", 10),
+		CancelRateBurst: getEnvInt("RATE_LIMIT_CANCELS_BURST", 40),
+		ReadRateLimit:   getEnvFloat("RATE_LIMIT_READS", 20),
+		ReadRateBurst:   getEnvInt("RATE_LIMIT_READS_BURST", 60),
+		AuthRateLimit:   getEnvFloat("RATE_LIMIT_AUTH", 0.2),
+		AuthRateBurst:   getEnvInt("RATE_LIMIT_AUTH_BURST", 10),
+		UserIPRateLimit: getEnvFloat("RATE_LIMIT_USER_IP", 30),
+		UserIPRateBurst: getEnvInt("RATE_LIMIT_USER_IP_BURST", 100),
+
+		RiskMaxOpenOrders:     getEnvInt("RISK_MAX_OPEN_ORDERS", 200),
+		RiskMaxPosition:       getEnvDecimal("RISK_MAX_POSITION", decimal.NewFromInt(100000)),
+		RiskMaxMarketNotional: getEnvDecimal("RISK_MAX_MARKET_NOTIONAL", decimal.NewFromInt(50000)),
+		RiskMaxDailyVolume:    getEnvDecimal("RISK_MAX_DAILY_VOLUME", decimal.NewFromInt(250000)),
+
+		IdempotencyKeyTTL: getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+
+		CORSPublicOrigins:    getEnvList("CORS_PUBLIC_ORIGINS", []string{"*"}),
+		CORSUserOrigins:      userOrigins,
+		CORSAdminOrigins:     getEnvList("CORS_ADMIN_ORIGINS", userOrigins),
+		CORSAllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAge:           getEnvDuration("CORS_MAX_AGE", 10*time.Minute),
+		HSTSMaxAge:           getEnvDuration("HSTS_MAX_AGE", 0),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", nil),
 	}
 }
 
@@ -43,3 +204,62 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList reads a comma-separated list
+func getEnvList(key string, defaultValue []string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return defaultValue
+	}
+	return list
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDecimal(key string, defaultValue decimal.Decimal) decimal.Decimal {
+	if value := os.Getenv(key); value != "" {
+		if d, err := decimal.NewFromString(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
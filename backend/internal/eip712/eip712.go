@@ -0,0 +1,149 @@
+// Package eip712 hashes EIP-712 typed data and EIP-191 personal messages
+// and recovers the Ethereum address that signed them.
+package eip712
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// Keccak256 is the legacy Keccak hash used by Ethereum
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// Domain is the EIP-712 signing domain: EIP712Domain(string name,string
+// version,uint256 chainId)
+type Domain struct {
+	Name    string
+	Version string
+	ChainID int64
+}
+
+var domainTypeHash = Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId)"))
+
+// Separator returns the domain separator
+func (d Domain) Separator() []byte {
+	return Keccak256(
+		domainTypeHash,
+		String(d.Name),
+		String(d.Version),
+		Uint(big.NewInt(d.ChainID)),
+	)
+}
+
+// Hash returns the digest that is signed for a struct in this domain:
+// keccak256("\x19\x01" ‖ domainSeparator ‖ structHash)
+func (d Domain) Hash(structHash []byte) []byte {
+	return Keccak256([]byte{0x19, 0x01}, d.Separator(), structHash)
+}
+
+// TypeHash hashes an encoded struct type such as
+// "Mail(address from,address to,string contents)"
+func TypeHash(encodedType string) []byte {
+	return Keccak256([]byte(encodedType))
+}
+
+// StructHash hashes a type hash followed by its encoded fields
+func StructHash(typeHash []byte, fields ...[]byte) []byte {
+	return Keccak256(append([][]byte{typeHash}, fields...)...)
+}
+
+// String encodes a string field as the hash of its bytes
+func String(s string) []byte {
+	return Keccak256([]byte(s))
+}
+
+// Bytes encodes a dynamic bytes field as its hash
+func Bytes(b []byte) []byte {
+	return Keccak256(b)
+}
+
+// Uint encodes a uint256 field as a 32-byte big-endian word
+func Uint(n *big.Int) []byte {
+	word := make([]byte, 32)
+	n.FillBytes(word)
+	return word
+}
+
+// Bytes32 encodes a bytes32 field
+func Bytes32(b []byte) []byte {
+	word := make([]byte, 32)
+	copy(word, b)
+	return word
+}
+
+// Address encodes an address field, left-padded to 32 bytes
+func Address(address string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(address), "0x"))
+	if err != nil || len(raw) != 20 {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+	word := make([]byte, 32)
+	copy(word[12:], raw)
+	return word, nil
+}
+
+// PersonalHash returns the EIP-191 digest signed by personal_sign:
+// keccak256("\x19Ethereum Signed Message:\n" ‖ len(message) ‖ message)
+func PersonalHash(message []byte) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return Keccak256([]byte(prefix), message)
+}
+
+// IsAddress reports whether s is a 0x-prefixed 20-byte hex address
+func IsAddress(s string) bool {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
+// Recover returns the lowercase 0x address that produced a 65-byte
+// r ‖ s ‖ v signature over hash. v may be 0/1 or 27/28.
+func Recover(hash []byte, signature string) (string, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return "", errors.New("signature must be 65 hex-encoded bytes")
+	}
+	if len(hash) != 32 {
+		return "", errors.New("hash must be 32 bytes")
+	}
+
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", errors.New("invalid signature recovery id")
+	}
+
+	// Only low-s signatures are accepted so a signature has a single encoding
+	var s secp256k1.ModNScalar
+	if overflow := s.SetByteSlice(sig[32:64]); overflow || s.IsOverHalfOrder() {
+		return "", errors.New("signature s value is not canonical")
+	}
+
+	// RecoverCompact takes v ‖ r ‖ s with v = 27 + recovery id for
+	// uncompressed keys
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return "", errors.New("invalid signature")
+	}
+	return publicKeyAddress(pub), nil
+}
@@ -0,0 +1,56 @@
+package eip712
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// PrivateKey is a secp256k1 signing key
+type PrivateKey struct {
+	key *secp256k1.PrivateKey
+}
+
+// ParsePrivateKey parses a hex-encoded 32-byte private key
+func ParsePrivateKey(s string) (*PrivateKey, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, errors.New("private key must be 32 hex-encoded bytes")
+	}
+	var d secp256k1.ModNScalar
+	if overflow := d.SetByteSlice(raw); overflow || d.IsZero() {
+		return nil, errors.New("private key out of range")
+	}
+	return &PrivateKey{key: secp256k1.NewPrivateKey(&d)}, nil
+}
+
+// Address returns the lowercase 0x address of the key
+func (k *PrivateKey) Address() string {
+	return publicKeyAddress(k.key.PubKey())
+}
+
+// Sign signs a 32-byte hash and returns the 0x-hex r ‖ s ‖ v signature
+// with v = 27 or 28, as produced by Ethereum wallets. Signatures use
+// RFC 6979 nonces and low s.
+func (k *PrivateKey) Sign(hash []byte) (string, error) {
+	if len(hash) != 32 {
+		return "", errors.New("hash must be 32 bytes")
+	}
+
+	// The compact format is v ‖ r ‖ s with v = 27 + recovery id for
+	// uncompressed keys
+	compact := ecdsa.SignCompact(k.key, hash, false)
+	sig := make([]byte, 65)
+	copy(sig, compact[1:])
+	sig[64] = compact[0]
+	return "0x" + hex.EncodeToString(sig), nil
+}
+
+// publicKeyAddress derives the lowercase 0x address of a public key
+func publicKeyAddress(pub *secp256k1.PublicKey) string {
+	// Drop the 0x04 prefix of the uncompressed encoding
+	return "0x" + hex.EncodeToString(Keccak256(pub.SerializeUncompressed()[1:])[12:])
+}
@@ -0,0 +1,86 @@
+package eip712
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+// RequestType is the typed struct a wallet signs to authenticate an API
+// request. Path includes the query string.
+const RequestType = "Request(string method,string path,bytes32 bodyHash,uint256 timestamp,string nonce)"
+
+var requestTypeHash = TypeHash(RequestType)
+
+// RequestHash returns the digest a wallet signs for an API request
+func RequestHash(domain Domain, method, path string, body []byte, timestamp int64, nonce string) []byte {
+	return domain.Hash(StructHash(
+		requestTypeHash,
+		String(method),
+		String(path),
+		Bytes32(Keccak256(body)),
+		Uint(big.NewInt(timestamp)),
+		String(nonce),
+	))
+}
+
+// OrderType is the typed struct a wallet signs to authorise an order.
+// Price and size are fixed-point with OrderDecimals decimals, the
+// precision of the USDC collateral; expiry is a unix time, 0 for none.
+const OrderType = "Order(address maker,uint256 marketId,uint8 outcome,string side,uint256 price,uint256 size,uint256 nonce,uint256 expiry)"
+
+// OrderDecimals is the fixed-point precision of order prices and sizes
+const OrderDecimals = 6
+
+var orderTypeHash = TypeHash(OrderType)
+
+// Order is the signed form of an order
+type Order struct {
+	Maker    string
+	MarketID uint64
+	Outcome  uint8
+	Side     string
+	Price    *big.Int
+	Size     *big.Int
+	Nonce    *big.Int
+	Expiry   int64
+}
+
+// Hash returns the digest a wallet signs for the order
+func (o Order) Hash(domain Domain) ([]byte, error) {
+	maker, err := Address(o.Maker)
+	if err != nil {
+		return nil, err
+	}
+	return domain.Hash(StructHash(
+		orderTypeHash,
+		maker,
+		Uint(new(big.Int).SetUint64(o.MarketID)),
+		Uint(big.NewInt(int64(o.Outcome))),
+		String(o.Side),
+		Uint(o.Price),
+		Uint(o.Size),
+		Uint(o.Nonce),
+		Uint(big.NewInt(o.Expiry)),
+	)), nil
+}
+
+// Units converts a decimal amount to OrderDecimals fixed point. Amounts
+// with more precision than that cannot be signed exactly and are rejected.
+func Units(d decimal.Decimal) (*big.Int, error) {
+	scaled := d.Shift(OrderDecimals)
+	if !scaled.IsInteger() || scaled.IsNegative() {
+		return nil, fmt.Errorf("%s is not a non-negative amount with at most %d decimals", d, OrderDecimals)
+	}
+	return scaled.BigInt(), nil
+}
+
+// ParseUint256 parses a decimal uint256 such as an order nonce
+func ParseUint256(s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid uint256 %q", s)
+	}
+	return n, nil
+}
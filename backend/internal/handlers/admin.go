@@ -2,130 +2,568 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
 	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/admins"
+	"github.com/prediction-market/backend/internal/services/amm"
+	"github.com/prediction-market/backend/internal/services/multisig"
+	"github.com/prediction-market/backend/internal/services/oracle"
+	"github.com/prediction-market/backend/internal/services/resolution"
+	"github.com/prediction-market/backend/internal/services/settlement"
+	"github.com/shopspring/decimal"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 type AdminHandler struct {
-	db *gorm.DB
+	db         *gorm.DB
+	amm        *amm.Service
+	resolution *resolution.Service
+	multisig   *multisig.Service
+	oracle     oracle.Policy
 }
 
-func NewAdminHandler(db *gorm.DB) *AdminHandler {
-	return &AdminHandler{db: db}
+func NewAdminHandler(db *gorm.DB, ammService *amm.Service, resolutionService *resolution.Service, multisigService *multisig.Service, oraclePolicy oracle.Policy) *AdminHandler {
+	return &AdminHandler{db: db, amm: ammService, resolution: resolutionService, multisig: multisigService, oracle: oraclePolicy}
+}
+
+// requireRole reports whether the signed-in admin holds role and responds
+// with 403 when not
+func requireRole(c *gin.Context, role models.AdminRole) bool {
+	value, _ := c.Get("admin_claims")
+	if claims, ok := value.(*admins.Claims); ok && claims.HasRole(role) {
+		return true
+	}
+	c.Error(apierr.New(apierr.CodeRoleRequired, string(role)+" role required"))
+	return false
 }
 
 type CreateMarketRequest struct {
 	Question       string    `json:"question" binding:"required"`
 	Description    string    `json:"description"`
-	Outcomes       []string  `json:"outcomes" binding:"required,min=2"`
+	Category       string    `json:"category" binding:"max=64"`
+	Tags           []string  `json:"tags"`
+	Type           string    `json:"type" binding:"omitempty,oneof=categorical scalar"`
+	Outcomes       []string  `json:"outcomes"`
 	EndTime        time.Time `json:"end_time" binding:"required"`
 	ResolutionTime time.Time `json:"resolution_time" binding:"required"`
+	// Scalar markets pay LONG linearly between ScalarLow and ScalarHigh
+	ScalarLow  *decimal.Decimal `json:"scalar_low"`
+	ScalarHigh *decimal.Decimal `json:"scalar_high"`
+	// Optional LMSR liquidity parameter b; the platform funds b·ln(n)
+	AMMLiquidity *decimal.Decimal `json:"amm_liquidity"`
+	// Optional oracle that proposes the resolution at ResolutionTime
+	Oracle *oracle.Spec `json:"oracle"`
 }
 
 func (h *AdminHandler) CreateMarket(c *gin.Context) {
-	isAdmin, _ := c.Get("admin")
-	if isAdmin != true {
-		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+	if !requireRole(c, models.AdminRoleMarketCreator) {
 		return
 	}
 
 	var req CreateMarketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierr.Invalid(err))
 		return
 	}
 
 	if req.EndTime.Before(time.Now()) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "end time must be in the future"})
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "end time must be in the future"))
 		return
 	}
 
 	if req.ResolutionTime.Before(req.EndTime) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "resolution time must be after end time"})
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "resolution time must be after end time"))
+		return
+	}
+
+	if req.AMMLiquidity != nil && req.AMMLiquidity.LessThanOrEqual(decimal.Zero) {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "amm liquidity must be positive"))
 		return
 	}
 
+	marketType := models.MarketTypeCategorical
+	if req.Type != "" {
+		marketType = models.MarketType(req.Type)
+	}
+
+	switch marketType {
+	case models.MarketTypeScalar:
+		if req.ScalarLow == nil || req.ScalarHigh == nil {
+			c.Error(apierr.New(apierr.CodeInvalidRequest, "scalar markets require scalar_low and scalar_high"))
+			return
+		}
+		if !req.ScalarLow.LessThan(*req.ScalarHigh) {
+			c.Error(apierr.New(apierr.CodeInvalidRequest, "scalar_low must be less than scalar_high"))
+			return
+		}
+		if len(req.Outcomes) != 0 {
+			c.Error(apierr.New(apierr.CodeInvalidRequest, "scalar markets have fixed LONG/SHORT outcomes"))
+			return
+		}
+		req.Outcomes = models.ScalarOutcomes
+	default:
+		if len(req.Outcomes) < 2 {
+			c.Error(apierr.New(apierr.CodeInvalidRequest, "at least two outcomes are required"))
+			return
+		}
+		if req.ScalarLow != nil || req.ScalarHigh != nil {
+			c.Error(apierr.New(apierr.CodeInvalidRequest, "scalar bounds are only valid for scalar markets"))
+			return
+		}
+	}
+
 	outcomesJSON, err := json.Marshal(req.Outcomes)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process outcomes"})
+		c.Error(apierr.Internal(err))
 		return
 	}
 
+	tagsJSON, err := json.Marshal(models.NormalizeTags(req.Tags))
+	if err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	var oracleJSON datatypes.JSON
+	if req.Oracle != nil {
+		if err := req.Oracle.Validate(marketType, len(req.Outcomes), h.oracle); err != nil {
+			c.Error(apierr.Invalid(err))
+			return
+		}
+		if oracleJSON, err = json.Marshal(req.Oracle); err != nil {
+			c.Error(apierr.Internal(err))
+			return
+		}
+	}
+
 	market := models.Market{
 		Question:       req.Question,
 		Description:    req.Description,
+		Category:       strings.TrimSpace(req.Category),
+		Tags:           datatypes.JSON(tagsJSON),
+		Type:           marketType,
 		Outcomes:       datatypes.JSON(outcomesJSON),
+		ScalarLow:      req.ScalarLow,
+		ScalarHigh:     req.ScalarHigh,
 		EndTime:        req.EndTime,
 		ResolutionTime: req.ResolutionTime,
+		Oracle:         oracleJSON,
 		Status:         models.MarketStatusActive,
 	}
 
-	if err := h.db.Create(&market).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&market).Error; err != nil {
+			return err
+		}
+		if req.AMMLiquidity != nil {
+			if _, err := h.amm.CreatePool(tx, market.ID, *req.AMMLiquidity, len(req.Outcomes)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, settlement.ErrInsufficientFunds) {
+			c.Error(apierr.New(apierr.CodeInsufficientBalance, "treasury cannot fund the amm pool"))
+			return
+		}
+		c.Error(apierr.Internal(err))
 		return
 	}
 
 	c.JSON(http.StatusCreated, market)
 }
 
+type UpdateMarketRequest struct {
+	Category *string  `json:"category" binding:"omitempty,max=64"`
+	Tags     []string `json:"tags"`
+}
+
+// UpdateMarket edits a market's category and tags
+func (h *AdminHandler) UpdateMarket(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleMarketCreator) {
+		return
+	}
+
+	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	var req UpdateMarketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	var market models.Market
+	if err := h.db.First(&market, marketID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(apierr.New(apierr.CodeMarketNotFound, "market not found"))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Category != nil {
+		updates["category"] = strings.TrimSpace(*req.Category)
+	}
+	if req.Tags != nil {
+		tagsJSON, err := json.Marshal(models.NormalizeTags(req.Tags))
+		if err != nil {
+			c.Error(apierr.Internal(err))
+			return
+		}
+		updates["tags"] = datatypes.JSON(tagsJSON)
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(&market).Updates(updates).Error; err != nil {
+			c.Error(apierr.Internal(err))
+			return
+		}
+		if err := h.db.First(&market, marketID).Error; err != nil {
+			c.Error(apierr.Internal(err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, market)
+}
+
 type ResolveMarketRequest struct {
-	Outcome uint8 `json:"outcome" binding:"required"`
+	// Winning outcome for categorical markets
+	Outcome uint8 `json:"outcome"`
+	// Observed value for scalar markets
+	Value *decimal.Decimal `json:"value"`
+	// Per-share payout of every outcome, summing to 1. Used for ties,
+	// split or invalid resolutions; overrides Outcome and Value.
+	Payouts []decimal.Decimal `json:"payouts"`
+}
+
+func (r *ResolveMarketRequest) proposal() resolution.Proposal {
+	return resolution.Proposal{
+		Outcome: r.Outcome,
+		Value:   r.Value,
+		Payouts: r.Payouts,
+	}
 }
 
+// ResolveMarket proposes a resolution and opens the dispute window; payouts
+// happen in FinalizeMarket
 func (h *AdminHandler) ResolveMarket(c *gin.Context) {
-	isAdmin, _ := c.Get("admin")
-	if isAdmin != true {
-		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+	if !requireRole(c, models.AdminRoleResolver) {
 		return
 	}
 
 	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid market id"})
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	payload, ok := h.collectApproval(c, marketID, models.AdminActionResolve)
+	if !ok {
 		return
 	}
 
 	var req ResolveMarketRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	market, err := h.resolution.Propose(marketID, req.proposal(), h.approvals(marketID, models.AdminActionResolve, payload))
+	if err != nil {
+		respondResolutionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, market)
+}
+
+type CancelMarketRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelMarket voids a market and releases all locked funds without payouts
+func (h *AdminHandler) CancelMarket(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleResolver) {
+		return
+	}
+
+	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	payload, ok := h.collectApproval(c, marketID, models.AdminActionCancel)
+	if !ok {
+		return
+	}
+
+	var req CancelMarketRequest
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			c.Error(apierr.Invalid(err))
+			return
+		}
+	}
+
+	market, err := h.resolution.Cancel(marketID, h.approvals(marketID, models.AdminActionCancel, payload))
+	if err != nil {
+		respondResolutionError(c, err)
+		return
+	}
+
+	log.Printf("admin: cancelled market %d: %s", marketID, req.Reason)
+	c.JSON(http.StatusOK, market)
+}
+
+// ApprovalRequest carries one admin's signed approval of an action. The
+// signature covers multisig.Message(action, market id, payload), so every
+// admin must submit byte-identical payloads for approvals to add up.
+type ApprovalRequest struct {
+	Payload   json.RawMessage `json:"payload" binding:"required"`
+	Signer    string          `json:"signer" binding:"required"`
+	Signature string          `json:"signature" binding:"required"`
+}
+
+type ApprovalPendingResponse struct {
+	Action models.AdminAction `json:"action"`
+	*multisig.Status
+}
+
+// collectApproval returns the action payload once the action may execute.
+// Without configured signers the request body is the payload. Otherwise
+// the signed approval is recorded and, until the threshold is reached,
+// 202 Accepted is written and ok is false.
+func (h *AdminHandler) collectApproval(c *gin.Context, marketID uint64, action models.AdminAction) (payload []byte, ok bool) {
+	if !h.multisig.Enabled() {
+		body, err := c.GetRawData()
+		if err != nil {
+			c.Error(apierr.Invalid(err))
+			return nil, false
+		}
+		return body, true
+	}
+
+	var req ApprovalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierr.Invalid(err))
+		return nil, false
+	}
+
+	status, err := h.multisig.Approve(marketID, action, req.Payload, req.Signer, req.Signature)
+	if err != nil {
+		switch {
+		case errors.Is(err, multisig.ErrUnknownSigner), errors.Is(err, multisig.ErrInvalidSignature):
+			c.Error(apierr.New(apierr.CodeApprovalRejected, err.Error()))
+		case errors.Is(err, multisig.ErrAlreadyApproved):
+			c.Error(apierr.New(apierr.CodeAlreadyApproved, err.Error()))
+		default:
+			c.Error(apierr.Internal(err))
+		}
+		return nil, false
+	}
+
+	if !status.Reached {
+		c.JSON(http.StatusAccepted, ApprovalPendingResponse{Action: action, Status: status})
+		return nil, false
+	}
+	return req.Payload, true
+}
+
+// approvals closes the approvals behind an action as part of it. Failed
+// actions leave them open so that any signer can submit again to retry.
+func (h *AdminHandler) approvals(marketID uint64, action models.AdminAction, payload []byte) resolution.Approvals {
+	if !h.multisig.Enabled() {
+		return nil
+	}
+	return func(tx *gorm.DB) error {
+		return h.multisig.MarkExecuted(tx, marketID, action, payload)
+	}
+}
+
+func (h *AdminHandler) ListApprovals(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleResolver) {
+		return
+	}
+
+	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	approvals, err := h.multisig.Pending(marketID)
+	if err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"threshold": h.multisig.Threshold(),
+		"approvals": approvals,
+	})
+}
+
+type FinalizeMarketRequest struct {
+	// Replaces the proposed resolution when set
+	Override *ResolveMarketRequest `json:"override"`
+}
+
+// FinalizeMarket confirms or overturns a proposed resolution and pays out
+func (h *AdminHandler) FinalizeMarket(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleResolver) {
+		return
+	}
+
+	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	// Overrides settle at once, skipping the dispute window, so finalising
+	// needs the same approvals as resolving
+	payload, ok := h.collectApproval(c, marketID, models.AdminActionFinalize)
+	if !ok {
+		return
+	}
+
+	var req FinalizeMarketRequest
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			c.Error(apierr.Invalid(err))
+			return
+		}
+	}
+
+	var override *resolution.Proposal
+	if req.Override != nil {
+		p := req.Override.proposal()
+		override = &p
+	}
+
+	market, err := h.resolution.Finalize(marketID, override, h.approvals(marketID, models.AdminActionFinalize, payload))
+	if err != nil {
+		respondResolutionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, market)
+}
+
+// respondResolutionError maps resolution service errors to API errors
+func respondResolutionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, resolution.ErrMarketNotFound):
+		c.Error(apierr.New(apierr.CodeMarketNotFound, err.Error()))
+	case errors.Is(err, resolution.ErrAlreadyDisputed):
+		c.Error(apierr.New(apierr.CodeAlreadyDisputed, err.Error()))
+	case errors.Is(err, resolution.ErrInvalidResolution):
+		c.Error(apierr.New(apierr.CodeInvalidResolution, err.Error()))
+	case errors.Is(err, resolution.ErrInsufficientBond):
+		c.Error(apierr.New(apierr.CodeInsufficientBalance, err.Error()))
+	case errors.Is(err, resolution.ErrInvalidState),
+		errors.Is(err, resolution.ErrWindowClosed),
+		errors.Is(err, resolution.ErrWindowOpen):
+		c.Error(apierr.New(apierr.CodeInvalidMarketState, err.Error()))
+	default:
+		c.Error(apierr.Internal(err))
+	}
+}
+
+type SetRewardPoolRequest struct {
+	AmountPerEpoch decimal.Decimal `json:"amount_per_epoch" binding:"required"`
+}
+
+func (h *AdminHandler) SetRewardPool(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleTreasury) {
+		return
+	}
+
+	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	var req SetRewardPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	if req.AmountPerEpoch.IsNegative() {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "reward amount cannot be negative"))
 		return
 	}
 
 	var market models.Market
 	if err := h.db.First(&market, marketID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "market not found"})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(apierr.New(apierr.CodeMarketNotFound, "market not found"))
+			return
+		}
+		c.Error(apierr.Internal(err))
 		return
 	}
 
-	if market.Status != models.MarketStatusActive {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "market is not active"})
+	pool := models.RewardPool{
+		MarketID:       market.ID,
+		AmountPerEpoch: req.AmountPerEpoch,
+	}
+	if err := h.db.Save(&pool).Error; err != nil {
+		c.Error(apierr.Internal(err))
 		return
 	}
 
-	var outcomes []string
-	if err := json.Unmarshal(market.Outcomes, &outcomes); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "corrupted market data"})
+	c.JSON(http.StatusOK, pool)
+}
+
+// GetTradeSettlement returns the payload to settle a trade on-chain,
+// including the signed orders of both parties
+func (h *AdminHandler) GetTradeSettlement(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleTreasury) {
 		return
 	}
 
-	if int(req.Outcome) < 1 || int(req.Outcome) > len(outcomes) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid outcome"})
+	tradeID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid trade id"))
 		return
 	}
 
-	market.ResolvedOutcome = &req.Outcome
-	market.Status = models.MarketStatusResolved
+	var trade models.Trade
+	if err := h.db.First(&trade, tradeID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierr.New(apierr.CodeTradeNotFound, "trade not found"))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
 
-	if err := h.db.Save(&market).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	payload, err := settlement.BuildTradePayload(h.db, &trade)
+	if err != nil {
+		c.Error(apierr.Internal(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, market)
+	c.JSON(http.StatusOK, payload)
 }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/admins"
+)
+
+type AdminAccountHandler struct {
+	admins *admins.Service
+}
+
+func NewAdminAccountHandler(adminService *admins.Service) *AdminAccountHandler {
+	return &AdminAccountHandler{admins: adminService}
+}
+
+type AdminLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type CreateAdminAccountRequest struct {
+	Username string   `json:"username" binding:"required,max=64"`
+	Password string   `json:"password" binding:"required"`
+	Roles    []string `json:"roles" binding:"required"`
+}
+
+// UpdateAdminAccountRequest changes the fields that are present
+type UpdateAdminAccountRequest struct {
+	Roles    []string `json:"roles"`
+	Password *string  `json:"password"`
+	Disabled *bool    `json:"disabled"`
+}
+
+// Login exchanges an admin's username and password for an access token
+func (h *AdminAccountHandler) Login(c *gin.Context) {
+	var req AdminLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	token, err := h.admins.Login(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, admins.ErrInvalidCredentials) {
+			c.Error(apierr.New(apierr.CodeUnauthorized, err.Error()))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+func (h *AdminAccountHandler) ListAccounts(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleSuperAdmin) {
+		return
+	}
+
+	accounts, err := h.admins.List()
+	if err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (h *AdminAccountHandler) CreateAccount(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleSuperAdmin) {
+		return
+	}
+
+	var req CreateAdminAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	account, err := h.admins.Create(req.Username, req.Password, req.Roles)
+	if err != nil {
+		if errors.Is(err, admins.ErrUsernameTaken) {
+			c.Error(apierr.New(apierr.CodeConflict, err.Error()))
+			return
+		}
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+func (h *AdminAccountHandler) UpdateAccount(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleSuperAdmin) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid account id"))
+		return
+	}
+
+	var req UpdateAdminAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	account, err := h.admins.Update(id, req.Roles, req.Password, req.Disabled)
+	if err != nil {
+		if errors.Is(err, admins.ErrAccountNotFound) {
+			c.Error(apierr.New(apierr.CodeNotFound, err.Error()))
+			return
+		}
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/apikeys"
+)
+
+type APIKeyHandler struct {
+	keys *apikeys.Service
+}
+
+func NewAPIKeyHandler(keys *apikeys.Service) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+type CreateAPIKeyRequest struct {
+	Label      string     `json:"label" binding:"max=64"`
+	Scopes     []string   `json:"scopes" binding:"required"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse carries the secret, which is only ever returned here
+type CreateAPIKeyResponse struct {
+	*models.APIKey
+	Secret string `json:"secret"`
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userAddress, ok := c.Get("user_address")
+	if !ok {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	userAddr, ok := userAddress.(string)
+	if !ok || userAddr == "" {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	key, secret, err := h.keys.Create(userAddr, apikeys.CreateRequest{
+		Label:      req.Label,
+		Scopes:     req.Scopes,
+		AllowedIPs: req.AllowedIPs,
+		ExpiresAt:  req.ExpiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, apikeys.ErrTooManyKeys) {
+			c.Error(apierr.New(apierr.CodeConflict, err.Error()))
+			return
+		}
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKey: key, Secret: secret})
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userAddress, ok := c.Get("user_address")
+	if !ok {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	userAddr, ok := userAddress.(string)
+	if !ok || userAddr == "" {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
+		return
+	}
+
+	keys, err := h.keys.List(userAddr)
+	if err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userAddress, ok := c.Get("user_address")
+	if !ok {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	userAddr, ok := userAddress.(string)
+	if !ok || userAddr == "" {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid api key id"))
+		return
+	}
+
+	if err := h.keys.Revoke(userAddr, id); err != nil {
+		if errors.Is(err, apikeys.ErrKeyNotFound) {
+			c.Error(apierr.New(apierr.CodeNotFound, err.Error()))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
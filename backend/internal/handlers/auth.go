@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/services/session"
+)
+
+type AuthHandler struct {
+	sessions *session.Service
+}
+
+func NewAuthHandler(sessions *session.Service) *AuthHandler {
+	return &AuthHandler{sessions: sessions}
+}
+
+type SignInRequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// GetNonce issues a nonce to embed in a Sign-In with Ethereum message
+func (h *AuthHandler) GetNonce(c *gin.Context) {
+	nonce, err := h.sessions.NewNonce()
+	if err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"nonce": nonce.Nonce, "expires_at": nonce.ExpiresAt})
+}
+
+// SignIn verifies a signed SIWE message and returns session tokens
+func (h *AuthHandler) SignIn(c *gin.Context) {
+	var req SignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	tokens, err := h.sessions.SignIn(req.Message, req.Signature)
+	if err != nil {
+		if errors.Is(err, session.ErrInvalidMessage) || errors.Is(err, session.ErrInvalidNonce) {
+			c.Error(apierr.New(apierr.CodeUnauthorized, err.Error()))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh exchanges a refresh token for new session tokens. The old refresh
+// token stops working.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	tokens, err := h.sessions.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, session.ErrInvalidToken) {
+			c.Error(apierr.New(apierr.CodeUnauthorized, err.Error()))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes a refresh token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	if err := h.sessions.Revoke(req.RefreshToken); err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
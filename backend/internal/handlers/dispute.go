@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/resolution"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type DisputeHandler struct {
+	db         *gorm.DB
+	resolution *resolution.Service
+}
+
+func NewDisputeHandler(db *gorm.DB, resolutionService *resolution.Service) *DisputeHandler {
+	return &DisputeHandler{db: db, resolution: resolutionService}
+}
+
+type CreateDisputeRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// Optional suggested payout vector
+	Payouts []decimal.Decimal `json:"payouts"`
+}
+
+func (h *DisputeHandler) CreateDispute(c *gin.Context) {
+	userAddress, ok := c.Get("user_address")
+	if !ok {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	userAddr, ok := userAddress.(string)
+	if !ok || userAddr == "" {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
+		return
+	}
+
+	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	var req CreateDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	dispute, err := h.resolution.Dispute(marketID, userAddr, req.Reason, req.Payouts)
+	if err != nil {
+		respondResolutionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dispute)
+}
+
+func (h *DisputeHandler) ListDisputes(c *gin.Context) {
+	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	disputes := make([]models.Dispute, 0)
+	if err := h.db.Where("market_id = ?", marketID).Order("created_at").Find(&disputes).Error; err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, disputes)
+}
@@ -1,24 +1,36 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
 	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/amm"
+	"github.com/prediction-market/backend/internal/services/candles"
+	"github.com/prediction-market/backend/internal/services/stats"
 	"gorm.io/gorm"
 )
 
 type MarketHandler struct {
-	db *gorm.DB
+	db    *gorm.DB
+	amm   *amm.Service
+	stats *stats.Service
 }
 
-func NewMarketHandler(db *gorm.DB) *MarketHandler {
-	return &MarketHandler{db: db}
+func NewMarketHandler(db *gorm.DB, ammService *amm.Service, statsService *stats.Service) *MarketHandler {
+	return &MarketHandler{db: db, amm: ammService, stats: statsService}
 }
 
+// marketSearchVector must match the expression of idx_markets_search
+const marketSearchVector = "to_tsvector('simple', question || ' ' || coalesce(description, ''))"
+
 func (h *MarketHandler) List(c *gin.Context) {
-	var markets []models.Market
+	markets := make([]models.Market, 0)
 
 	query := h.db.Model(&models.Market{})
 
@@ -26,46 +38,240 @@ func (h *MarketHandler) List(c *gin.Context) {
 		query = query.Where("status = ?", status)
 	}
 
-	if err := query.Order("created_at DESC").Find(&markets).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	if tag := c.Query("tag"); tag != "" {
+		tagJSON, _ := json.Marshal(models.NormalizeTags([]string{tag}))
+		query = query.Where("tags @> ?::jsonb", string(tagJSON))
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where(marketSearchVector+" @@ plainto_tsquery('simple', ?)", q)
+	}
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+	query = page.timeRange(query, "markets.created_at")
+
+	sort := c.DefaultQuery("sort", "newest")
+	switch sort {
+	case "newest":
+		query, err = page.keyset(query, "markets.created_at", "markets.id", true, timeKey)
+	case "ending_soon":
+		query = query.Where("end_time > ?", time.Now())
+		query, err = page.keyset(query, "markets.end_time", "markets.id", false, timeKey)
+	case "volume":
+		query, err = page.keyset(query, "markets.volume", "markets.id", true, decimalKey)
+	default:
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "sort must be one of volume, ending_soon, newest"))
+		return
+	}
+	if err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	if err := query.Find(&markets).Error; err != nil {
+		c.Error(apierr.Internal(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, markets)
+	var response PageResponse
+	if page.hasMore(len(markets)) {
+		markets = markets[:page.Limit]
+
+		last := markets[len(markets)-1]
+		switch sort {
+		case "newest":
+			response.NextCursor = encodeCursor(formatTimeKey(last.CreatedAt), last.ID)
+		case "ending_soon":
+			response.NextCursor = encodeCursor(formatTimeKey(last.EndTime), last.ID)
+		case "volume":
+			response.NextCursor = encodeCursor(last.Volume.String(), last.ID)
+		}
+	}
+
+	withStats := make([]models.MarketWithStats, len(markets))
+	for i := range markets {
+		withStats[i] = h.stats.Market(&markets[i])
+	}
+	response.Data = withStats
+
+	c.JSON(http.StatusOK, response)
+}
+
+type CategoryCount struct {
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+}
+
+func (h *MarketHandler) ListCategories(c *gin.Context) {
+	categories := make([]CategoryCount, 0)
+	if err := h.db.Model(&models.Market{}).
+		Select("category, COUNT(*) AS count").
+		Where("category <> ''").
+		Group("category").
+		Order("count DESC").
+		Scan(&categories).Error; err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
 }
 
 func (h *MarketHandler) Get(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid market id"})
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
 		return
 	}
 
 	var market models.Market
 	if err := h.db.First(&market, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "market not found"})
+			c.Error(apierr.New(apierr.CodeMarketNotFound, "market not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierr.Internal(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, market)
+	c.JSON(http.StatusOK, h.stats.Market(&market))
 }
 
 func (h *MarketHandler) GetTrades(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid market id"})
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	query := page.timeRange(h.db.Where("market_id = ?", id), "created_at")
+	query, err = page.keyset(query, "created_at", "id", true, timeKey)
+	if err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	trades := make([]models.Trade, 0)
+	if err := query.Find(&trades).Error; err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	response := PageResponse{Data: trades}
+	if page.hasMore(len(trades)) {
+		trades = trades[:page.Limit]
+		last := trades[len(trades)-1]
+		response.Data = trades
+		response.NextCursor = encodeCursor(formatTimeKey(last.CreatedAt), last.ID)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *MarketHandler) GetAMMQuote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	quote, err := h.amm.GetQuote(id)
+	if err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+	if quote == nil {
+		c.Error(apierr.New(apierr.CodeNotFound, "market has no amm"))
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+const (
+	// defaultCandles is how many candles are returned when from is omitted
+	defaultCandles = 500
+	// maxCandles caps the number of candles one request can span
+	maxCandles = 5000
+)
+
+func (h *MarketHandler) GetCandles(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	outcome, err := strconv.ParseUint(c.DefaultQuery("outcome", "1"), 10, 8)
+	if err != nil || outcome < 1 {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid outcome"))
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "1h")
+	width, ok := candles.Intervals[interval]
+	if !ok {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "interval must be one of 1m, 5m, 1h, 1d"))
 		return
 	}
 
-	var trades []models.Trade
-	if err := h.db.Where("market_id = ?", id).Order("created_at DESC").Limit(100).Find(&trades).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid from: "+err.Error()))
+		return
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid to: "+err.Error()))
+		return
+	}
+
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultCandles * width)
+	if from != nil {
+		start = *from
+	}
+	if !start.Before(end) {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "from must be before to"))
+		return
+	}
+	if end.Sub(start)/width > maxCandles {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "time range spans too many candles"))
+		return
+	}
+
+	var market models.Market
+	if err := h.db.First(&market, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierr.New(apierr.CodeMarketNotFound, "market not found"))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	result, err := candles.Query(h.db, id, uint8(outcome), interval, start, end)
+	if err != nil {
+		c.Error(apierr.Internal(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, trades)
+	c.JSON(http.StatusOK, result)
 }
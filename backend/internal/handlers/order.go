@@ -1,31 +1,59 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/eip712"
 	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/amm"
+	"github.com/prediction-market/backend/internal/services/candles"
+	"github.com/prediction-market/backend/internal/services/feed"
 	"github.com/prediction-market/backend/internal/services/orderbook"
+	"github.com/prediction-market/backend/internal/services/risk"
+	"github.com/prediction-market/backend/internal/services/settlement"
+	"github.com/prediction-market/backend/internal/services/stats"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderHandler struct {
-	db  *gorm.DB
-	obm *orderbook.OrderBookManager
+	db     *gorm.DB
+	obm    *orderbook.OrderBookManager
+	amm    *amm.Service
+	stats  *stats.Service
+	hub    *feed.Hub
+	risk   *risk.Service
+	domain eip712.Domain
 }
 
-func NewOrderHandler(db *gorm.DB, obm *orderbook.OrderBookManager) *OrderHandler {
-	return &OrderHandler{db: db, obm: obm}
+func NewOrderHandler(db *gorm.DB, obm *orderbook.OrderBookManager, ammService *amm.Service, statsService *stats.Service, hub *feed.Hub, riskService *risk.Service, domain eip712.Domain) *OrderHandler {
+	return &OrderHandler{db: db, obm: obm, amm: ammService, stats: statsService, hub: hub, risk: riskService, domain: domain}
 }
 
+// PlaceOrderRequest is an order with the user's EIP-712 signature of it as
+// an eip712.OrderType struct. Nonce is a decimal uint256 that the user may
+// not reuse; Expiry is a unix time, 0 for none. ClientOrderID is an
+// optional unsigned label, unique per user, that makes retries safe and
+// can be used to cancel the order.
 type PlaceOrderRequest struct {
-	MarketID uint64          `json:"market_id" binding:"required"`
-	Outcome  uint8           `json:"outcome" binding:"required"`
-	Side     string          `json:"side" binding:"required,oneof=buy sell"`
-	Price    decimal.Decimal `json:"price" binding:"required"`
-	Quantity decimal.Decimal `json:"quantity" binding:"required"`
+	MarketID  uint64          `json:"market_id" binding:"required"`
+	Outcome   uint8           `json:"outcome" binding:"required"`
+	Side      string          `json:"side" binding:"required,oneof=buy sell"`
+	Price     decimal.Decimal `json:"price" binding:"required"`
+	Quantity  decimal.Decimal `json:"quantity" binding:"required"`
+	Nonce     string          `json:"nonce" binding:"required"`
+	Expiry    int64           `json:"expiry" binding:"min=0"`
+	Signature string          `json:"signature" binding:"required"`
+
+	ClientOrderID string `json:"client_order_id" binding:"max=64"`
 }
 
 type PlaceOrderResponse struct {
@@ -46,18 +74,24 @@ type PriceLevelResponse struct {
 func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	userAddress, ok := c.Get("user_address")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
 		return
 	}
 	userAddr, ok := userAddress.(string)
 	if !ok || userAddr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user address"})
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
 		return
 	}
 
 	var req PlaceOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	// A retry of an order that was already placed gets the original
+	// placement back
+	if req.ClientOrderID != "" && h.replayOrder(c, userAddr, req.ClientOrderID, req.Nonce) {
 		return
 	}
 
@@ -65,7 +99,7 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	minPrice := decimal.NewFromFloat(0.01)
 	maxPrice := decimal.NewFromFloat(0.99)
 	if req.Price.LessThan(minPrice) || req.Price.GreaterThan(maxPrice) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be between 0.01 and 0.99"})
+		c.Error(apierr.New(apierr.CodePriceOutOfRange, "price must be between 0.01 and 0.99"))
 		return
 	}
 
@@ -73,15 +107,32 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	var market models.Market
 	if err := h.db.First(&market, req.MarketID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "market not found"})
+			c.Error(apierr.New(apierr.CodeMarketNotFound, "market not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierr.Internal(err))
 		return
 	}
 
 	if market.Status != models.MarketStatusActive {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "market is not active"})
+		c.Error(apierr.New(apierr.CodeMarketClosed, "market is not active"))
+		return
+	}
+
+	if err := h.verifyOrder(userAddr, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var nonceUsed int64
+	if err := h.db.Model(&models.Order{}).
+		Where("user_address = ? AND nonce = ?", userAddr, req.Nonce).
+		Count(&nonceUsed).Error; err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+	if nonceUsed > 0 {
+		c.Error(apierr.New(apierr.CodeNonceUsed, "order nonce already used"))
 		return
 	}
 
@@ -97,31 +148,60 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 		Quantity:       req.Quantity,
 		FilledQuantity: decimal.Zero,
 		Status:         models.OrderStatusOpen,
+		Nonce:          &req.Nonce,
+		Expiry:         req.Expiry,
+		Signature:      req.Signature,
+	}
+	if req.ClientOrderID != "" {
+		order.ClientOrderID = &req.ClientOrderID
 	}
 
 	// Start DB transaction FIRST
 	tx := h.db.Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction"})
+		c.Error(apierr.Internal(tx.Error))
 		return
 	}
 
-	// Lock and check balance inside transaction for buy orders
-	if side == models.OrderSideBuy {
-		requiredBalance := req.Price.Mul(req.Quantity)
+	// Enforce the user's exposure limits
+	if err := h.risk.Check(tx, order, time.Now()); err != nil {
+		tx.Rollback()
+		if errors.Is(err, risk.ErrLimitExceeded) {
+			c.Error(apierr.New(apierr.CodeRiskLimitExceeded, err.Error()))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	// Sells of shares the user does not hold are backed by collateral
+	if side == models.OrderSideSell {
+		uncovered, err := settlement.Uncovered(tx, order)
+		if err != nil {
+			tx.Rollback()
+			c.Error(apierr.Internal(err))
+			return
+		}
+		order.Uncovered = uncovered
+	}
+
+	// Lock and check balance inside transaction: the price of a buy, or
+	// 1 − price per uncovered share of a sell
+	var lockedBalance *models.UserBalance
+	if requiredBalance := order.LockedFor(order.Quantity); requiredBalance.IsPositive() {
 
 		// Lock the balance row with SELECT FOR UPDATE
 		var balance models.UserBalance
 		if err := tx.Set("gorm:query_option", "FOR UPDATE").
 			FirstOrCreate(&balance, models.UserBalance{UserAddress: userAddr}).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to lock balance"})
+			c.Error(apierr.Internal(err))
 			return
 		}
 
 		if balance.Available.LessThan(requiredBalance) {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient balance"})
+			c.Error(apierr.New(apierr.CodeInsufficientBalance, "insufficient balance"))
 			return
 		}
 
@@ -130,26 +210,86 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 		balance.Locked = balance.Locked.Add(requiredBalance)
 		if err := tx.Save(&balance).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(apierr.Internal(err))
 			return
 		}
+		lockedBalance = &balance
 	}
 
-	// Save order to DB
-	if err := tx.Create(order).Error; err != nil {
+	// Save order to DB. A concurrent retry with the same client order id
+	// may have been placed since the check above; it wins and this request
+	// gets its placement back.
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_address"}, {Name: "client_order_id"}},
+		DoNothing: true,
+	}).Create(order)
+	if result.Error != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// A concurrent order with the same nonce got in after the check
+		// above
+		if uniqueViolation(result.Error, "idx_order_user_nonce") {
+			c.Error(apierr.New(apierr.CodeNonceUsed, "order nonce already used"))
+			return
+		}
+		c.Error(apierr.Internal(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		if !h.replayOrder(c, userAddr, req.ClientOrderID, req.Nonce) {
+			c.Error(apierr.New(apierr.CodeClientOrderIDUsed, "client order id already used"))
+		}
 		return
 	}
 
-	// Add order to orderbook
 	ob := h.obm.GetOrCreate(req.MarketID, req.Outcome)
-	matchResult, err := ob.AddOrder(order)
+
+	// Route against the AMM first while it beats the best resting price on
+	// the other side
+	trades := make([]models.Trade, 0)
+	openInterest := make([]decimal.Decimal, 0)
+	var (
+		ammTrade *models.Trade
+		err      error
+	)
+	if side == models.OrderSideBuy {
+		bestAsk, hasAsk := ob.BestAsk()
+		ammTrade, err = h.amm.FillBuy(tx, order, bestAsk, hasAsk)
+	} else {
+		bestBid, hasBid := ob.BestBid()
+		ammTrade, err = h.amm.FillSell(tx, order, bestBid, hasBid)
+	}
 	if err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add order to orderbook: " + err.Error()})
+		c.Error(apierr.Internal(fmt.Errorf("route order to amm: %w", err)))
 		return
 	}
+	if ammTrade != nil {
+		delta, err := settlement.RecordTrade(tx, ammTrade, side)
+		if err != nil {
+			tx.Rollback()
+			c.Error(apierr.Internal(err))
+			return
+		}
+		if err := candles.RecordTrade(tx, ammTrade); err != nil {
+			tx.Rollback()
+			c.Error(apierr.Internal(err))
+			return
+		}
+		trades = append(trades, *ammTrade)
+		openInterest = append(openInterest, delta)
+	}
+
+	// Add the remainder to orderbook
+	matchResult := &orderbook.MatchResult{TakerOrder: order}
+	if order.RemainingQuantity().GreaterThan(decimal.Zero) {
+		matchResult, err = ob.AddOrder(order)
+		if err != nil {
+			tx.Rollback()
+			c.Error(apierr.Internal(fmt.Errorf("add order to orderbook: %w", err)))
+			return
+		}
+	}
 
 	// Save trades from MatchResult
 	for i := range matchResult.Trades {
@@ -158,7 +298,31 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 			// Rollback orderbook changes on DB failure
 			ob.RemoveOrder(order)
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(apierr.Internal(err))
+			return
+		}
+		delta, err := settlement.RecordTrade(tx, &matchResult.Trades[i], side)
+		if err != nil {
+			ob.RemoveOrder(order)
+			tx.Rollback()
+			c.Error(apierr.Internal(err))
+			return
+		}
+		openInterest = append(openInterest, delta)
+		if err := candles.RecordTrade(tx, &matchResult.Trades[i]); err != nil {
+			ob.RemoveOrder(order)
+			tx.Rollback()
+			c.Error(apierr.Internal(err))
+			return
+		}
+	}
+
+	// Cancel resting orders that expired before they could be filled
+	for _, expired := range matchResult.Expired {
+		if err := releaseOrder(tx, expired); err != nil {
+			ob.RemoveOrder(order)
+			tx.Rollback()
+			c.Error(apierr.Internal(err))
 			return
 		}
 	}
@@ -174,7 +338,7 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 			// Rollback orderbook changes on DB failure
 			ob.RemoveOrder(order)
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(apierr.Internal(err))
 			return
 		}
 	}
@@ -189,7 +353,7 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 		// Rollback orderbook changes on DB failure
 		ob.RemoveOrder(order)
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierr.Internal(err))
 		return
 	}
 
@@ -197,31 +361,165 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	if err := tx.Commit().Error; err != nil {
 		// Rollback orderbook changes on commit failure
 		ob.RemoveOrder(order)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierr.Internal(err))
 		return
 	}
 
+	trades = append(trades, matchResult.Trades...)
+	for i := range trades {
+		h.stats.RecordTrade(trades[i], openInterest[i])
+		h.hub.PublishTrade(trades[i])
+		h.hub.PublishFill(trades[i], side)
+	}
+	h.hub.PublishOrder(order)
+	for _, makerOrder := range matchResult.MakerOrders {
+		h.hub.PublishOrder(makerOrder)
+	}
+	if lockedBalance != nil {
+		h.hub.PublishBalance(lockedBalance)
+	}
+	for _, expired := range matchResult.Expired {
+		h.hub.PublishOrder(expired)
+		if expired.UnfilledLocked().IsPositive() {
+			var balance models.UserBalance
+			if err := h.db.First(&balance, "user_address = ?", expired.UserAddress).Error; err == nil {
+				h.hub.PublishBalance(&balance)
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, PlaceOrderResponse{
 		Order:  order,
-		Trades: matchResult.Trades,
+		Trades: trades,
 	})
 }
 
+// pgUniqueViolation is the Postgres SQLSTATE of a unique violation
+const pgUniqueViolation = "23505"
+
+// uniqueViolation reports whether err is a violation of the named unique
+// index
+func uniqueViolation(err error, index string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == index
+}
+
+// replayOrder responds with the order the user already placed under
+// clientOrderID, and its trades, and reports whether there was one. The
+// id is reused if that order was signed with a different nonce.
+func (h *OrderHandler) replayOrder(c *gin.Context, userAddr, clientOrderID, nonce string) bool {
+	var existing models.Order
+	err := h.db.Where("user_address = ? AND client_order_id = ?", userAddr, clientOrderID).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	if err != nil {
+		c.Error(apierr.Internal(err))
+		return true
+	}
+
+	// Stored nonces are canonical; compare the request's in the same form
+	if n, err := eip712.ParseUint256(nonce); err == nil {
+		nonce = n.String()
+	}
+	if existing.Nonce == nil || *existing.Nonce != nonce {
+		c.Error(apierr.New(apierr.CodeClientOrderIDUsed, "client order id already used"))
+		return true
+	}
+
+	trades := make([]models.Trade, 0)
+	if err := h.db.Where("taker_order_id = ?", existing.ID).Order("id ASC").Find(&trades).Error; err != nil {
+		c.Error(apierr.Internal(err))
+		return true
+	}
+	c.JSON(http.StatusOK, PlaceOrderResponse{Order: &existing, Trades: trades})
+	return true
+}
+
+// verifyOrder checks that the request is signed by the user and has not
+// expired. Errors are *apierr.Error.
+func (h *OrderHandler) verifyOrder(userAddr string, req *PlaceOrderRequest) error {
+	if req.Expiry > 0 && time.Now().Unix() >= req.Expiry {
+		return apierr.New(apierr.CodeOrderExpired, "order has expired")
+	}
+
+	// Prices are stored with four decimals; anything finer would be
+	// rounded away from what was signed
+	if !req.Price.Equal(req.Price.Truncate(4)) {
+		return apierr.New(apierr.CodePriceOutOfRange, "price may have at most 4 decimals")
+	}
+
+	nonce, err := eip712.ParseUint256(req.Nonce)
+	if err != nil {
+		return apierr.New(apierr.CodeInvalidRequest, "nonce must be a decimal uint256")
+	}
+	price, err := eip712.Units(req.Price)
+	if err != nil {
+		return apierr.New(apierr.CodeInvalidRequest, "invalid price: "+err.Error())
+	}
+	size, err := eip712.Units(req.Quantity)
+	if err != nil {
+		return apierr.New(apierr.CodeInvalidRequest, "invalid quantity: "+err.Error())
+	}
+	// Store the canonical form so the signed nonce can be rebuilt
+	req.Nonce = nonce.String()
+
+	hash, err := eip712.Order{
+		Maker:    userAddr,
+		MarketID: req.MarketID,
+		Outcome:  req.Outcome,
+		Side:     req.Side,
+		Price:    price,
+		Size:     size,
+		Nonce:    nonce,
+		Expiry:   req.Expiry,
+	}.Hash(h.domain)
+	if err != nil {
+		return apierr.Invalid(err)
+	}
+	signer, err := eip712.Recover(hash, req.Signature)
+	if err != nil || signer != userAddr {
+		return apierr.New(apierr.CodeInvalidSignature, "invalid order signature")
+	}
+	return nil
+}
+
+// releaseOrder cancels an order that left the book without the owner
+// cancelling it and unlocks the funds of its unfilled quantity
+func releaseOrder(tx *gorm.DB, order *models.Order) error {
+	if err := tx.Model(&models.Order{}).
+		Where("id = ?", order.ID).
+		Update("status", models.OrderStatusCancelled).Error; err != nil {
+		return err
+	}
+
+	unlockAmount := order.UnfilledLocked()
+	if !unlockAmount.GreaterThan(decimal.Zero) {
+		return nil
+	}
+	return tx.Model(&models.UserBalance{}).
+		Where("user_address = ?", order.UserAddress).
+		Updates(map[string]interface{}{
+			"available": gorm.Expr("available + ?", unlockAmount),
+			"locked":    gorm.Expr("locked - ?", unlockAmount),
+		}).Error
+}
+
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	userAddress, ok := c.Get("user_address")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
 		return
 	}
 	userAddr, ok := userAddress.(string)
 	if !ok || userAddr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user address"})
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
 		return
 	}
 
 	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid order id"))
 		return
 	}
 
@@ -229,45 +527,81 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	var order models.Order
 	if err := h.db.First(&order, orderID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			c.Error(apierr.New(apierr.CodeOrderNotFound, "order not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierr.Internal(err))
 		return
 	}
 
 	// Verify order belongs to user
 	if order.UserAddress != userAddr {
-		c.JSON(http.StatusForbidden, gin.H{"error": "order does not belong to user"})
+		c.Error(apierr.New(apierr.CodeForbidden, "order does not belong to user"))
+		return
+	}
+
+	h.cancelOrder(c, &order)
+}
+
+// CancelOrderByClientID cancels one of the user's orders by the
+// client_order_id it was placed with
+func (h *OrderHandler) CancelOrderByClientID(c *gin.Context) {
+	userAddress, ok := c.Get("user_address")
+	if !ok {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	userAddr, ok := userAddress.(string)
+	if !ok || userAddr == "" {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
 		return
 	}
 
+	var order models.Order
+	if err := h.db.Where("user_address = ? AND client_order_id = ?", userAddr, c.Param("client_order_id")).
+		First(&order).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierr.New(apierr.CodeOrderNotFound, "order not found"))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	h.cancelOrder(c, &order)
+}
+
+// cancelOrder cancels an order of the authenticated user and unlocks the
+// funds it reserved
+func (h *OrderHandler) cancelOrder(c *gin.Context, order *models.Order) {
+	userAddr := order.UserAddress
+
 	// Check order is Open or Partial
 	if order.Status != models.OrderStatusOpen && order.Status != models.OrderStatusPartial {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "order cannot be cancelled"})
+		c.Error(apierr.New(apierr.CodeOrderNotCancellable, "order cannot be cancelled"))
 		return
 	}
 
-	// Calculate amount to unlock (remaining quantity * price)
-	unlockAmount := order.RemainingQuantity().Mul(order.Price)
+	// Calculate amount to unlock: the collateral of the unfilled quantity
+	unlockAmount := order.UnfilledLocked()
 
 	// Start transaction
 	tx := h.db.Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction"})
+		c.Error(apierr.Internal(tx.Error))
 		return
 	}
 
 	// Update order status first
 	order.Status = models.OrderStatusCancelled
-	if err := tx.Save(&order).Error; err != nil {
+	if err := tx.Save(order).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierr.Internal(err))
 		return
 	}
 
-	// Unlock balance for buy orders
-	if order.Side == models.OrderSideBuy && unlockAmount.GreaterThan(decimal.Zero) {
+	// Unlock the balance it reserved
+	if unlockAmount.GreaterThan(decimal.Zero) {
 		result := tx.Model(&models.UserBalance{}).
 			Where("user_address = ?", userAddr).
 			Updates(map[string]interface{}{
@@ -276,20 +610,28 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 			})
 		if result.Error != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			c.Error(apierr.Internal(result.Error))
 			return
 		}
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierr.Internal(err))
 		return
 	}
 
 	// Only AFTER commit succeeds, remove from orderbook
 	ob := h.obm.GetOrCreate(order.MarketID, order.Outcome)
-	ob.RemoveOrder(&order)
+	ob.RemoveOrder(order)
+
+	h.hub.PublishOrder(order)
+	if unlockAmount.GreaterThan(decimal.Zero) {
+		var balance models.UserBalance
+		if err := h.db.First(&balance, "user_address = ?", userAddr).Error; err == nil {
+			h.hub.PublishBalance(&balance)
+		}
+	}
 
 	c.JSON(http.StatusOK, order)
 }
@@ -297,35 +639,59 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 	userAddress, ok := c.Get("user_address")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
 		return
 	}
 	userAddr, ok := userAddress.(string)
 	if !ok || userAddr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user address"})
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
+		return
+	}
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.Error(apierr.Invalid(err))
 		return
 	}
 
-	var orders []models.Order
+	orders := make([]models.Order, 0)
 	query := h.db.Where("user_address = ?", userAddr)
 
-	// Optionally filter by status
+	// Optionally filter by status and market
 	if status := c.Query("status"); status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if marketID := c.Query("market_id"); marketID != "" {
+		query = query.Where("market_id = ?", marketID)
+	}
+
+	query = page.timeRange(query, "created_at")
+	query, err = page.keyset(query, "created_at", "id", true, timeKey)
+	if err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
 
-	if err := query.Order("created_at DESC").Limit(100).Find(&orders).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := query.Find(&orders).Error; err != nil {
+		c.Error(apierr.Internal(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	response := PageResponse{Data: orders}
+	if page.hasMore(len(orders)) {
+		orders = orders[:page.Limit]
+		last := orders[len(orders)-1]
+		response.Data = orders
+		response.NextCursor = encodeCursor(formatTimeKey(last.CreatedAt), last.ID)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *OrderHandler) GetOrderBook(c *gin.Context) {
 	marketID, err := strconv.ParseUint(c.Param("market_id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid market id"})
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
 		return
 	}
 
@@ -334,7 +700,7 @@ func (h *OrderHandler) GetOrderBook(c *gin.Context) {
 	if outcomeStr := c.Query("outcome"); outcomeStr != "" {
 		outcomeVal, err := strconv.ParseUint(outcomeStr, 10, 8)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid outcome"})
+			c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid outcome"))
 			return
 		}
 		outcome = uint8(outcomeVal)
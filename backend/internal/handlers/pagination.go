@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// PageResponse wraps one page of a list endpoint. NextCursor is empty on
+// the last page.
+type PageResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// cursor is the position after the last row of a page: its sort key and ID
+type cursor struct {
+	Key string `json:"k"`
+	ID  uint64 `json:"id"`
+}
+
+func encodeCursor(key string, id uint64) string {
+	data, _ := json.Marshal(cursor{Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cur cursor
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cur, nil
+}
+
+// pagination holds the cursor, limit and time-range query parameters
+type pagination struct {
+	Limit  int
+	Cursor *cursor
+	From   *time.Time
+	To     *time.Time
+}
+
+// parsePagination reads cursor, limit, from and to. Times are RFC 3339 or
+// unix seconds.
+func parsePagination(c *gin.Context) (*pagination, error) {
+	p := &pagination{Limit: defaultPageLimit}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return nil, errors.New("invalid limit")
+		}
+		p.Limit = min(n, maxPageLimit)
+	}
+
+	if cur := c.Query("cursor"); cur != "" {
+		decoded, err := decodeCursor(cur)
+		if err != nil {
+			return nil, err
+		}
+		p.Cursor = decoded
+	}
+
+	var err error
+	if p.From, err = parseTimeParam(c.Query("from")); err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	if p.To, err = parseTimeParam(c.Query("to")); err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+
+	return p, nil
+}
+
+func parseTimeParam(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t := time.Unix(secs, 0)
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// timeRange restricts column to [from, to)
+func (p *pagination) timeRange(query *gorm.DB, column string) *gorm.DB {
+	if p.From != nil {
+		query = query.Where(column+" >= ?", *p.From)
+	}
+	if p.To != nil {
+		query = query.Where(column+" < ?", *p.To)
+	}
+	return query
+}
+
+// keyset orders by (column, idColumn) and continues after the cursor. It
+// fetches one extra row so hasMore can tell whether another page exists.
+func (p *pagination) keyset(query *gorm.DB, column, idColumn string, desc bool, parseKey func(string) (interface{}, error)) (*gorm.DB, error) {
+	direction, cmp := "ASC", ">"
+	if desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if p.Cursor != nil {
+		key, err := parseKey(p.Cursor.Key)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", column, idColumn, cmp), key, p.Cursor.ID)
+	}
+
+	return query.
+		Order(column + " " + direction).
+		Order(idColumn + " " + direction).
+		Limit(p.Limit + 1), nil
+}
+
+// hasMore reports whether a fetched page of n rows has a following page
+func (p *pagination) hasMore(n int) bool {
+	return n > p.Limit
+}
+
+func timeKey(s string) (interface{}, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func formatTimeKey(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func decimalKey(s string) (interface{}, error) {
+	return decimal.NewFromString(s)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type RewardHandler struct {
+	db *gorm.DB
+}
+
+func NewRewardHandler(db *gorm.DB) *RewardHandler {
+	return &RewardHandler{db: db}
+}
+
+type UserRewardsResponse struct {
+	TotalPaid  decimal.Decimal      `json:"total_paid"`
+	Rewards    []models.MakerReward `json:"rewards"`
+	NextCursor string               `json:"next_cursor"`
+}
+
+func (h *RewardHandler) GetUserRewards(c *gin.Context) {
+	userAddress, ok := c.Get("user_address")
+	if !ok {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	userAddr, ok := userAddress.(string)
+	if !ok || userAddr == "" {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
+		return
+	}
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	query := h.db.Model(&models.MakerReward{}).Where("user_address = ?", userAddr)
+
+	// Optionally filter by market
+	if marketID := c.Query("market_id"); marketID != "" {
+		query = query.Where("market_id = ?", marketID)
+	}
+	query = page.timeRange(query, "epoch_start")
+
+	var totalPaid decimal.Decimal
+	if err := query.Session(&gorm.Session{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("paid = ?", true).
+		Scan(&totalPaid).Error; err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	query, err = page.keyset(query.Session(&gorm.Session{}), "epoch_start", "id", true, timeKey)
+	if err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	rewards := make([]models.MakerReward, 0)
+	if err := query.Find(&rewards).Error; err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	response := UserRewardsResponse{TotalPaid: totalPaid, Rewards: rewards}
+	if page.hasMore(len(rewards)) {
+		rewards = rewards[:page.Limit]
+		last := rewards[len(rewards)-1]
+		response.Rewards = rewards
+		response.NextCursor = encodeCursor(formatTimeKey(last.EpochStart), last.ID)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/eip712"
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/admins"
+	"github.com/prediction-market/backend/internal/services/risk"
+	"github.com/shopspring/decimal"
+)
+
+type RiskHandler struct {
+	risk *risk.Service
+}
+
+func NewRiskHandler(riskService *risk.Service) *RiskHandler {
+	return &RiskHandler{risk: riskService}
+}
+
+// SetRiskLimitsRequest replaces a user's override. Omitted or null fields
+// use the global default; zero removes the limit for the user.
+type SetRiskLimitsRequest struct {
+	MaxOpenOrders     *int             `json:"max_open_orders"`
+	MaxPosition       *decimal.Decimal `json:"max_position"`
+	MaxMarketNotional *decimal.Decimal `json:"max_market_notional"`
+	MaxDailyVolume    *decimal.Decimal `json:"max_daily_volume"`
+}
+
+// userParam returns the lowercased :address path parameter
+func userParam(c *gin.Context) (string, bool) {
+	address := strings.ToLower(c.Param("address"))
+	if !eip712.IsAddress(address) {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid user address"))
+		return "", false
+	}
+	return address, true
+}
+
+func (h *RiskHandler) GetRiskLimits(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleTreasury) {
+		return
+	}
+	address, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	limits, err := h.risk.Get(address)
+	if err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+func (h *RiskHandler) SetRiskLimits(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleTreasury) {
+		return
+	}
+	address, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	var req SetRiskLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierr.Invalid(err))
+		return
+	}
+
+	var updatedBy string
+	if claims, ok := c.Value("admin_claims").(*admins.Claims); ok {
+		updatedBy = claims.Username
+	}
+
+	limits, err := h.risk.Set(address, models.RiskLimit{
+		MaxOpenOrders:     req.MaxOpenOrders,
+		MaxPosition:       req.MaxPosition,
+		MaxMarketNotional: req.MaxMarketNotional,
+		MaxDailyVolume:    req.MaxDailyVolume,
+	}, updatedBy)
+	if err != nil {
+		if errors.Is(err, risk.ErrInvalidLimit) {
+			c.Error(apierr.Invalid(err))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// ClearRiskLimits removes a user's override
+func (h *RiskHandler) ClearRiskLimits(c *gin.Context) {
+	if !requireRole(c, models.AdminRoleTreasury) {
+		return
+	}
+	address, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.risk.Clear(address); err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "risk limits reset to defaults"})
+}
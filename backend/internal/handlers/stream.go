@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/feed"
+	"github.com/prediction-market/backend/internal/services/orderbook"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxStreamRequest caps the size of a client message
+	maxStreamRequest = 4096
+	// sseHeartbeat is how often an idle SSE stream sends a comment so
+	// proxies keep the connection open
+	sseHeartbeat = 15 * time.Second
+)
+
+type StreamHandler struct {
+	db  *gorm.DB
+	obm *orderbook.OrderBookManager
+	hub *feed.Hub
+}
+
+func NewStreamHandler(db *gorm.DB, obm *orderbook.OrderBookManager, hub *feed.Hub) *StreamHandler {
+	return &StreamHandler{db: db, obm: obm, hub: hub}
+}
+
+// StreamRequest is a client message on the WebSocket:
+// {"op": "subscribe" | "unsubscribe" | "ping", "channel": "book:1:1"}
+type StreamRequest struct {
+	Op      string `json:"op"`
+	Channel string `json:"channel"`
+}
+
+// StreamReply acknowledges a client message or reports an error
+type StreamReply struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ServeWS upgrades the request to a WebSocket carrying market data.
+// Channels are book:<market>:<outcome> (snapshot then deltas),
+// trades:<market> and status:<market>.
+func (h *StreamHandler) ServeWS(c *gin.Context) {
+	h.upgrade(c, "")
+}
+
+// ServeUserWS is ServeWS for an authenticated wallet. The connection is
+// subscribed to the wallet's private channel, which carries order state
+// changes, fills and balance updates, and can subscribe to public
+// channels as well.
+func (h *StreamHandler) ServeUserWS(c *gin.Context) {
+	userAddress, ok := c.Get("user_address")
+	if !ok {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	userAddr, ok := userAddress.(string)
+	if !ok || userAddr == "" {
+		c.Error(apierr.New(apierr.CodeUnauthorized, "invalid user address"))
+		return
+	}
+
+	h.upgrade(c, feed.UserChannel(userAddr))
+}
+
+// upgrade serves the WebSocket, subscribed to private when it is non-empty
+func (h *StreamHandler) upgrade(c *gin.Context, private string) {
+	server := websocket.Server{
+		// Accept clients without an Origin header, such as bots
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			h.serve(ws, private)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *StreamHandler) serve(ws *websocket.Conn, private string) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = maxStreamRequest
+
+	sub := h.hub.NewSubscriber()
+	defer h.hub.Remove(sub)
+	if private != "" {
+		h.hub.Subscribe(sub, private)
+	}
+
+	// Forward hub messages until the subscriber is dropped or the socket
+	// fails. Closing the socket also ends the read loop below.
+	go func() {
+		defer ws.Close()
+		for msg := range sub.C {
+			if err := websocket.JSON.Send(ws, msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			return
+		}
+
+		reply := StreamReply{Type: "error", Error: "invalid message"}
+		var req StreamRequest
+		if err := json.Unmarshal(data, &req); err == nil {
+			reply = h.handle(sub, req)
+		}
+		if err := websocket.JSON.Send(ws, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (h *StreamHandler) handle(sub *feed.Subscriber, req StreamRequest) StreamReply {
+	switch req.Op {
+	case "ping":
+		return StreamReply{Type: "pong"}
+	case "unsubscribe":
+		if strings.HasPrefix(req.Channel, "user:") {
+			return StreamReply{Type: "error", Channel: req.Channel, Error: "private channel cannot be unsubscribed"}
+		}
+		h.hub.Unsubscribe(sub, req.Channel)
+		return StreamReply{Type: "unsubscribed", Channel: req.Channel}
+	case "subscribe":
+	default:
+		return StreamReply{Type: "error", Error: "op must be one of subscribe, unsubscribe, ping"}
+	}
+
+	if err := h.subscribe(sub, req.Channel); err != nil {
+		return StreamReply{Type: "error", Channel: req.Channel, Error: err.Error()}
+	}
+	return StreamReply{Type: "subscribed", Channel: req.Channel}
+}
+
+func (h *StreamHandler) subscribe(sub *feed.Subscriber, channel string) error {
+	parts := strings.Split(channel, ":")
+	if len(parts) < 2 || (parts[0] != "book" && parts[0] != "trades" && parts[0] != "status") {
+		return errors.New("unknown channel")
+	}
+
+	marketID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return errors.New("invalid market id")
+	}
+	var market models.Market
+	if err := h.db.First(&market, marketID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("market not found")
+		}
+		return err
+	}
+
+	switch {
+	case parts[0] == "book" && len(parts) == 3:
+		var outcomes []string
+		if err := json.Unmarshal(market.Outcomes, &outcomes); err != nil {
+			return errors.New("corrupted market data")
+		}
+		outcome, err := strconv.ParseUint(parts[2], 10, 8)
+		if err != nil || outcome < 1 || int(outcome) > len(outcomes) {
+			return errors.New("invalid outcome")
+		}
+		h.hub.SubscribeBook(sub, h.obm.GetOrCreate(marketID, uint8(outcome)))
+	case parts[0] == "trades" && len(parts) == 2:
+		h.hub.Subscribe(sub, feed.TradesChannel(marketID))
+	case parts[0] == "status" && len(parts) == 2:
+		h.hub.Subscribe(sub, feed.StatusChannel(marketID))
+	default:
+		return errors.New("unknown channel")
+	}
+	return nil
+}
+
+// ServeSSE streams an outcome's trades and top-of-book changes as
+// Server-Sent Events. Clients that reconnect with Last-Event-ID receive the
+// events they missed while those are still in the in-memory journal.
+func (h *StreamHandler) ServeSSE(c *gin.Context) {
+	marketID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid market id"))
+		return
+	}
+
+	var market models.Market
+	if err := h.db.First(&market, marketID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apierr.New(apierr.CodeMarketNotFound, "market not found"))
+			return
+		}
+		c.Error(apierr.Internal(err))
+		return
+	}
+
+	var outcomes []string
+	if err := json.Unmarshal(market.Outcomes, &outcomes); err != nil {
+		c.Error(apierr.Internal(err))
+		return
+	}
+	outcome, err := strconv.ParseUint(c.DefaultQuery("outcome", "1"), 10, 8)
+	if err != nil || outcome < 1 || int(outcome) > len(outcomes) {
+		c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid outcome"))
+		return
+	}
+
+	// EventSource sends the header on reconnect; the query parameter lets
+	// other clients resume explicitly
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		if lastID, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			c.Error(apierr.New(apierr.CodeInvalidRequest, "invalid Last-Event-ID"))
+			return
+		}
+	}
+
+	sub := h.hub.NewSubscriber()
+	defer h.hub.Remove(sub)
+	replay := h.hub.SubscribeStream(sub, marketID, uint8(outcome), lastID)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range replay {
+		renderStreamEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and resumes
+				return
+			}
+			if event, ok := msg.Data.(feed.StreamEvent); ok {
+				renderStreamEvent(c, event)
+				c.Writer.Flush()
+			}
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func renderStreamEvent(c *gin.Context, event feed.StreamEvent) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: event.Type,
+		Data:  event.Data,
+	})
+}
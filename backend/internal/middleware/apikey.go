@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/services/apikeys"
+)
+
+// Authentication methods recorded as "auth_method" on the context
+const (
+	AuthMethodSession   = "session"
+	AuthMethodSignature = "signature"
+	AuthMethodAPIKey    = "api_key"
+)
+
+// APIKeyAuth authenticates a request signed with an API key. The client
+// sends:
+//
+//	X-API-Key        the key
+//	X-API-Timestamp  unix milliseconds, within maxSkew of server time
+//	X-API-Signature  hex HMAC-SHA256 with the secret of
+//	                 timestamp ‖ method ‖ path with query ‖ body
+//
+// A signature is accepted once; nonces remembers the ones already used.
+func APIKeyAuth(keys *apikeys.Service, maxSkew time.Duration, nonces NonceStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyID := c.GetHeader("X-API-Key")
+		signature := c.GetHeader("X-API-Signature")
+		if keyID == "" || signature == "" {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "missing api key signature"))
+			return
+		}
+
+		rawTimestamp := c.GetHeader("X-API-Timestamp")
+		timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+		if err != nil {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid timestamp"))
+			return
+		}
+		signedAt := time.UnixMilli(timestamp)
+		if skew := time.Since(signedAt); skew > maxSkew || skew < -maxSkew {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "request timestamp outside allowed window"))
+			return
+		}
+
+		key, secret, err := keys.Authenticate(keyID, c.ClientIP())
+		if err != nil {
+			if errors.Is(err, apikeys.ErrInvalidKey) {
+				abort(c, apierr.New(apierr.CodeUnauthorized, err.Error()))
+				return
+			}
+			if errors.Is(err, apikeys.ErrIPNotAllowed) {
+				abort(c, apierr.New(apierr.CodeForbidden, err.Error()))
+				return
+			}
+			abort(c, apierr.Internal(err))
+			return
+		}
+
+		body, ok := readBody(c)
+		if !ok {
+			return
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(rawTimestamp + c.Request.Method + c.Request.URL.RequestURI()))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(expected), []byte(signature)) {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid signature"))
+			return
+		}
+
+		if !nonces.Use("key:"+key.Key, signature, signedAt.Add(maxSkew)) {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "request already used"))
+			return
+		}
+		// Usage tracking is informational; a failed write must not block
+		// the request
+		_ = keys.Touch(key)
+
+		c.Set("user_address", key.UserAddress)
+		c.Set("auth_method", AuthMethodAPIKey)
+		c.Set("api_key_scopes", key.Scopes)
+		c.Set("api_key", key.Key)
+		c.Next()
+	}
+}
+
+// RequireScope rejects API key requests whose key does not grant scope.
+// Sessions and wallet-signed requests act with the wallet's full rights.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") == AuthMethodAPIKey &&
+			!apikeys.HasScope(c.GetString("api_key_scopes"), scope) {
+			abort(c, apierr.New(apierr.CodeForbidden, "api key lacks the "+scope+" scope"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession only admits requests authenticated by a Sign-In with
+// Ethereum session
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != AuthMethodSession {
+			abort(c, apierr.New(apierr.CodeForbidden, "sign in with ethereum required"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,60 +1,77 @@
 package middleware
 
 import (
-	"fmt"
-	"net/http"
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/services/admins"
+	"github.com/prediction-market/backend/internal/services/session"
 )
 
-func JWTAuth(secret string) gin.HandlerFunc {
+// JWTAuth authenticates an admin by the access token from admin login,
+// re-checking the account on every request, and stores its claims as
+// "admin_claims" for the handlers' role checks
+func JWTAuth(accounts *admins.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			abort(c, apierr.New(apierr.CodeUnauthorized, "missing authorization header"))
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid authorization header"))
 			return
 		}
 
-		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-			// Validate algorithm is HMAC
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		claims, err := accounts.Authenticate(parts[1])
+		if err != nil {
+			if errors.Is(err, admins.ErrInvalidToken) {
+				abort(c, apierr.New(apierr.CodeUnauthorized, "invalid token"))
+			} else {
+				abort(c, apierr.Internal(err))
 			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("admin", claims["admin"])
-		}
-
+		c.Set("admin_claims", claims)
 		c.Next()
 	}
 }
 
-func WalletAuth() gin.HandlerFunc {
+// UserAuth authenticates a wallet by, in order of precedence, a session
+// access token from Sign-In with Ethereum in the Authorization header, an
+// API key checked by apiKeyAuth, or a wallet-signed request checked by
+// walletAuth
+func UserAuth(secret string, apiKeyAuth, walletAuth gin.HandlerFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// For MVP: trust X-Wallet-Address header
-		// In production: implement EIP-712 signature verification
-		address := c.GetHeader("X-Wallet-Address")
-		if address == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing wallet address"})
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			if c.GetHeader("X-API-Key") != "" {
+				apiKeyAuth(c)
+				return
+			}
+			walletAuth(c)
+			return
+		}
+
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid authorization header"))
+			return
+		}
+
+		address, err := session.ParseAccessToken(secret, parts[1])
+		if err != nil {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid session token"))
 			return
 		}
 
-		c.Set("user_address", strings.ToLower(address))
+		c.Set("user_address", address)
+		c.Set("auth_method", AuthMethodSession)
 		c.Next()
 	}
 }
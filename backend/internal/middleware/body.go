@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+)
+
+// maxBodyBytes caps the request bodies middleware buffers before the
+// handler runs. Order and API key requests are a few hundred bytes.
+const maxBodyBytes = 1 << 20
+
+// readBody buffers the request body, up to maxBodyBytes, and puts it back
+// for the handler. On failure it records the error and aborts.
+func readBody(c *gin.Context) ([]byte, bool) {
+	if c.Request.Body == nil {
+		return nil, true
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			abort(c, apierr.New(apierr.CodeRequestTooLarge, "request body too large"))
+			return nil, false
+		}
+		abort(c, apierr.New(apierr.CodeInvalidRequest, "failed to read request body"))
+		return nil, false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
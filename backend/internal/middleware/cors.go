@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy is the cross-origin access a group of routes allows
+type CORSPolicy struct {
+	// Origins browsers may call from, such as "https://app.example.com";
+	// "*" allows any origin
+	AllowedOrigins []string
+	// Whether browsers may send cookies and read the response. Never sent
+	// for a wildcard origin.
+	AllowCredentials bool
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	// How long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or
+// false if the policy does not allow it
+func (p CORSPolicy) allowOrigin(origin string) (string, bool) {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return "*", true
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// setOrigin writes the headers common to preflight and actual responses.
+// Requests from origins outside the policy get none, so browsers block
+// them; non-browser clients are unaffected.
+func (p CORSPolicy) setOrigin(c *gin.Context) bool {
+	origin := c.GetHeader("Origin")
+	if origin == "" {
+		return false
+	}
+	allowed, ok := p.allowOrigin(origin)
+	if !ok {
+		return false
+	}
+
+	c.Header("Access-Control-Allow-Origin", allowed)
+	if allowed != "*" {
+		c.Writer.Header().Add("Vary", "Origin")
+		if p.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+	}
+	return true
+}
+
+// CORS sets the policy's headers on the responses of a route group
+func CORS(policy CORSPolicy) gin.HandlerFunc {
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	return func(c *gin.Context) {
+		if policy.setOrigin(c) && exposed != "" {
+			c.Header("Access-Control-Expose-Headers", exposed)
+		}
+		c.Next()
+	}
+}
+
+// Preflight answers OPTIONS requests. They never reach a group's CORS
+// middleware because no route is registered for OPTIONS, so policies maps
+// the path prefix each group is mounted at to its policy; the longest
+// matching prefix wins.
+func Preflight(policies map[string]CORSPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		var policy *CORSPolicy
+		matched := -1
+		for prefix, p := range policies {
+			if strings.HasPrefix(path, prefix) && len(prefix) > matched {
+				policy, matched = &p, len(prefix)
+			}
+		}
+
+		if policy != nil && c.GetHeader("Access-Control-Request-Method") != "" && policy.setOrigin(c) {
+			c.Header("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+			c.Header("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+			if policy.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+			}
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// SecurityHeaders sets headers that harden JSON API responses. HSTS is
+// sent when hstsMaxAge is positive, which only makes sense behind TLS.
+func SecurityHeaders(hstsMaxAge time.Duration) gin.HandlerFunc {
+	hsts := "max-age=" + strconv.Itoa(int(hstsMaxAge.Seconds())) + "; includeSubDomains"
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		h.Set("Cross-Origin-Opener-Policy", "same-origin")
+		if hstsMaxAge > 0 {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
+
+// NoStore stops browsers and proxies caching responses, for routes that
+// return a user's private data
+func NoStore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.Next()
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+)
+
+// Errors renders the last error a handler recorded with c.Error as
+// {"error": message, "code": code} with the status of its code. Errors
+// other than *apierr.Error are reported as internal without their
+// message; causes remain in c.Errors for the request log.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		renderError(c)
+	}
+}
+
+// renderError writes the response for a recorded error unless the handler
+// has already written one
+func renderError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	err := apierr.From(c.Errors.Last().Err)
+	c.JSON(err.Status(), gin.H{"error": err.Message, "code": err.Code})
+}
+
+// abort records err for Errors to render and stops the handler chain
+func abort(c *gin.Context, err *apierr.Error) {
+	c.Error(err)
+	c.Abort()
+}
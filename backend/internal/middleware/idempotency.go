@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/services/idempotency"
+)
+
+// maxIdempotencyKey is the longest Idempotency-Key accepted
+const maxIdempotencyKey = 64
+
+// responseRecorder keeps a copy of the response body
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes a user route safe to retry. A request with an
+// Idempotency-Key header is handled once per user and key; retries with
+// the same method, path and body get the stored response with an
+// Idempotent-Replayed header. Server errors and panics release the key,
+// since the handlers roll back before reporting them. Requests without
+// the header pass through.
+func Idempotency(keys *idempotency.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			abort(c, apierr.New(apierr.CodeInvalidRequest, "idempotency key too long"))
+			return
+		}
+		userAddr := c.GetString("user_address")
+
+		body, ok := readBody(c)
+		if !ok {
+			return
+		}
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		record, err := keys.Begin(userAddr, key, fingerprint, time.Now())
+		if err != nil {
+			switch {
+			case errors.Is(err, idempotency.ErrInProgress):
+				abort(c, apierr.New(apierr.CodeIdempotencyKeyInProgress, err.Error()))
+			case errors.Is(err, idempotency.ErrKeyReused):
+				abort(c, apierr.New(apierr.CodeIdempotencyKeyReused, err.Error()))
+			default:
+				abort(c, apierr.Internal(err))
+			}
+			return
+		}
+		if record != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, "application/json; charset=utf-8", record.Response)
+			c.Abort()
+			return
+		}
+
+		release := func() {
+			if err := keys.Release(userAddr, key); err != nil {
+				log.Printf("idempotency: failed to release key %q for %s: %v", key, userAddr, err)
+			}
+		}
+		// A panicking handler has changed nothing, so the key is freed for
+		// a retry before the panic reaches the recovery middleware
+		defer func() {
+			if r := recover(); r != nil {
+				release()
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		renderError(c)
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			release()
+			return
+		}
+		// The request has taken effect. If its response cannot be saved the
+		// key stays in progress until it expires, so that retries conflict
+		// rather than run again.
+		if err := keys.Complete(userAddr, key, status, recorder.body.Bytes()); err != nil {
+			log.Printf("idempotency: failed to save key %q for %s: %v", key, userAddr, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+)
+
+// Limit is a token bucket: up to Burst requests at once, refilled at Rate
+// requests per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until a token is available when not allowed
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps token buckets. MemoryRateLimitStore serves a single
+// instance; a shared store lets several instances enforce one limit.
+type RateLimitStore interface {
+	Take(key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket will be full if left alone
+}
+
+// MemoryRateLimitStore is an in-process RateLimitStore. Buckets that have
+// refilled are forgotten.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	pruned  time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty MemoryRateLimitStore
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.pruned) > time.Minute {
+		for k, b := range s.buckets {
+			if !now.Before(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.pruned = now
+	}
+
+	burst := float64(limit.Burst)
+	b := s.buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	result := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((burst - b.tokens) / limit.Rate)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RateLimit limits requests per client in the bucket named name. Clients
+// are identified by API key, then wallet, then IP, so it should run after
+// authentication on authenticated routes. Responses carry
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds
+// until the bucket is full); rejected requests get 429 with Retry-After.
+func RateLimit(name string, limit Limit, store RateLimitStore) gin.HandlerFunc {
+	return rateLimit(name, limit, store, rateLimitKey)
+}
+
+// RateLimitByIP limits requests per client IP regardless of credentials.
+// Placed in front of authentication it also counts requests that fail to
+// authenticate.
+func RateLimitByIP(name string, limit Limit, store RateLimitStore) gin.HandlerFunc {
+	return rateLimit(name, limit, store, func(c *gin.Context) string {
+		return "ip:" + c.ClientIP()
+	})
+}
+
+func rateLimit(name string, limit Limit, store RateLimitStore, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := store.Take(name+":"+key(c), limit, time.Now())
+		if err != nil {
+			// Fail open: an unavailable store must not take the API down
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			abort(c, apierr.New(apierr.CodeRateLimited, "rate limit exceeded"))
+			return
+		}
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *gin.Context) string {
+	if key := c.GetString("api_key"); key != "" {
+		return "key:" + key
+	}
+	if address := c.GetString("user_address"); address != "" {
+		return "wallet:" + address
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prediction-market/backend/internal/apierr"
+	"github.com/prediction-market/backend/internal/eip712"
+)
+
+// maxNonceLength caps the X-Nonce header
+const maxNonceLength = 64
+
+// NonceStore remembers the request nonces a wallet has used
+type NonceStore interface {
+	// Use records a nonce until expires and reports whether it was unused
+	Use(address, nonce string, expires time.Time) bool
+}
+
+// MemoryNonceStore is an in-process NonceStore. Nonces are forgotten once
+// their request timestamp falls outside the allowed skew, after which the
+// request is rejected as stale anyway.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	pruned time.Time
+}
+
+// NewMemoryNonceStore creates an empty MemoryNonceStore
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (s *MemoryNonceStore) Use(address, nonce string, expires time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.pruned) > time.Minute {
+		for key, exp := range s.nonces {
+			if now.After(exp) {
+				delete(s.nonces, key)
+			}
+		}
+		s.pruned = now
+	}
+
+	key := address + ":" + nonce
+	if exp, ok := s.nonces[key]; ok && now.Before(exp) {
+		return false
+	}
+	s.nonces[key] = expires
+	return true
+}
+
+// WalletAuth authenticates a request signed by a wallet. The client signs
+// the EIP-712 Request struct (method, path with query, keccak256 of the
+// body, unix timestamp and a nonce) in domain and sends:
+//
+//	X-Wallet-Address  the signing address
+//	X-Signature       the 65-byte hex signature
+//	X-Timestamp       the signed timestamp, within maxSkew of server time
+//	X-Nonce           the signed nonce, unique per wallet
+func WalletAuth(domain eip712.Domain, maxSkew time.Duration, nonces NonceStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := strings.ToLower(c.GetHeader("X-Wallet-Address"))
+		if address == "" {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "missing wallet address"))
+			return
+		}
+		if !eip712.IsAddress(address) {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid wallet address"))
+			return
+		}
+
+		signature := c.GetHeader("X-Signature")
+		if signature == "" {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "missing signature"))
+			return
+		}
+
+		timestamp, err := strconv.ParseInt(c.GetHeader("X-Timestamp"), 10, 64)
+		if err != nil {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid timestamp"))
+			return
+		}
+		signedAt := time.Unix(timestamp, 0)
+		if skew := time.Since(signedAt); skew > maxSkew || skew < -maxSkew {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "request timestamp outside allowed window"))
+			return
+		}
+
+		nonce := c.GetHeader("X-Nonce")
+		if nonce == "" || len(nonce) > maxNonceLength {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid nonce"))
+			return
+		}
+
+		body, ok := readBody(c)
+		if !ok {
+			return
+		}
+
+		hash := eip712.RequestHash(domain, c.Request.Method, c.Request.URL.RequestURI(), body, timestamp, nonce)
+		signer, err := eip712.Recover(hash, signature)
+		if err != nil || signer != address {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "invalid signature"))
+			return
+		}
+
+		// Only a verified signature consumes the nonce, so a forged request
+		// cannot burn nonces of another wallet
+		if !nonces.Use(address, nonce, signedAt.Add(maxSkew)) {
+			abort(c, apierr.New(apierr.CodeUnauthorized, "nonce already used"))
+			return
+		}
+
+		c.Set("user_address", address)
+		c.Set("auth_method", AuthMethodSignature)
+		c.Next()
+	}
+}
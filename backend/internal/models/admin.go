@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type AdminRole string
+
+const (
+	AdminRoleMarketCreator AdminRole = "market_creator"
+	AdminRoleResolver      AdminRole = "resolver"
+	AdminRoleTreasury      AdminRole = "treasury"
+	AdminRoleSuperAdmin    AdminRole = "super_admin" // every role, and manages admin accounts
+)
+
+// AdminRoles are the roles an admin account can hold
+var AdminRoles = []AdminRole{AdminRoleMarketCreator, AdminRoleResolver, AdminRoleTreasury, AdminRoleSuperAdmin}
+
+// AdminAccount is an operator who signs in to the admin API
+type AdminAccount struct {
+	ID           uint64     `gorm:"primaryKey" json:"id"`
+	Username     string     `gorm:"not null;size:64;uniqueIndex" json:"username"`
+	PasswordHash string     `gorm:"not null" json:"-"`
+	Roles        string     `gorm:"not null;size:128" json:"roles"` // comma-separated AdminRole values
+	Disabled     bool       `gorm:"not null;default:false" json:"disabled"`
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RoleList returns the account's roles
+func (a *AdminAccount) RoleList() []string {
+	if a.Roles == "" {
+		return nil
+	}
+	return strings.Split(a.Roles, ",")
+}
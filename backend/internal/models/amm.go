@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+// AMMPool holds the LMSR market maker state for a market
+type AMMPool struct {
+	MarketID   uint64          `gorm:"primaryKey" json:"market_id"`
+	Liquidity  decimal.Decimal `gorm:"not null;type:decimal(20,6)" json:"liquidity"`
+	Quantities datatypes.JSON  `gorm:"not null" json:"quantities"`
+	Funding    decimal.Decimal `gorm:"not null;type:decimal(20,6)" json:"funding"`
+	Enabled    bool            `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// APIKey lets a bot act for a wallet by signing requests with HMAC. The
+// secret is stored encrypted because verifying an HMAC needs it.
+type APIKey struct {
+	ID              uint64     `gorm:"primaryKey" json:"id"`
+	UserAddress     string     `gorm:"not null;size:42;index" json:"user_address"`
+	Key             string     `gorm:"not null;size:32;uniqueIndex" json:"key"`
+	EncryptedSecret string     `gorm:"not null" json:"-"`
+	Label           string     `gorm:"size:64" json:"label"`
+	Scopes          string     `gorm:"not null;size:64" json:"scopes"` // comma-separated
+	AllowedIPs      string     `gorm:"size:1024" json:"allowed_ips"`   // comma-separated IPs or CIDRs, empty for any
+	ExpiresAt       *time.Time `json:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type AdminAction string
+
+const (
+	AdminActionResolve  AdminAction = "resolve"
+	AdminActionCancel   AdminAction = "cancel"
+	AdminActionFinalize AdminAction = "finalize"
+)
+
+// AdminApproval is one admin's signed approval of an action on a market.
+// Approvals of the same action and payload count towards its threshold.
+type AdminApproval struct {
+	ID          uint64         `gorm:"primaryKey" json:"id"`
+	MarketID    uint64         `gorm:"not null;uniqueIndex:idx_admin_approval" json:"market_id"`
+	Action      AdminAction    `gorm:"not null;size:20;uniqueIndex:idx_admin_approval" json:"action"`
+	PayloadHash string         `gorm:"not null;size:64;uniqueIndex:idx_admin_approval" json:"payload_hash"`
+	Signer      string         `gorm:"not null;size:64;uniqueIndex:idx_admin_approval" json:"signer"`
+	Payload     datatypes.JSON `json:"payload"`
+	Signature   string         `gorm:"not null" json:"signature"`
+	Executed    bool           `gorm:"not null;default:false" json:"executed"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Candle is the OHLCV aggregate of an outcome's trades over one interval
+// starting at StartTime (UTC). Volume is in shares.
+type Candle struct {
+	MarketID  uint64          `gorm:"primaryKey" json:"market_id"`
+	Outcome   uint8           `gorm:"primaryKey" json:"outcome"`
+	Interval  string          `gorm:"primaryKey;size:3" json:"interval"`
+	StartTime time.Time       `gorm:"primaryKey" json:"start_time"`
+	Open      decimal.Decimal `gorm:"not null;type:decimal(10,4)" json:"open"`
+	High      decimal.Decimal `gorm:"not null;type:decimal(10,4)" json:"high"`
+	Low       decimal.Decimal `gorm:"not null;type:decimal(10,4)" json:"low"`
+	Close     decimal.Decimal `gorm:"not null;type:decimal(10,4)" json:"close"`
+	Volume    decimal.Decimal `gorm:"not null;type:decimal(20,6)" json:"volume"`
+	Trades    int64           `gorm:"not null" json:"trades"`
+}
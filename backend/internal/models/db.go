@@ -11,16 +11,50 @@ func InitDB(databaseURL string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// Markets predating the volume column get it filled from their trades
+	backfillVolume := !db.Migrator().HasColumn(&Market{}, "Volume")
+
 	err = db.AutoMigrate(
 		&Market{},
 		&Order{},
 		&Trade{},
 		&UserBalance{},
 		&BalanceLog{},
+		&RewardPool{},
+		&MakerReward{},
+		&AMMPool{},
+		&Position{},
+		&Dispute{},
+		&AdminApproval{},
+		&Candle{},
+		&AuthNonce{},
+		&RefreshToken{},
+		&APIKey{},
+		&AdminAccount{},
+		&RiskLimit{},
+		&IdempotencyKey{},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if backfillVolume {
+		if err := db.Exec(`UPDATE markets SET volume = t.volume FROM
+			(SELECT market_id, SUM(price * quantity) AS volume FROM trades GROUP BY market_id) t
+			WHERE t.market_id = markets.id`).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Full-text search over question and description, and tag containment
+	for _, stmt := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_markets_search ON markets USING GIN (to_tsvector('simple', question || ' ' || coalesce(description, '')))`,
+		`CREATE INDEX IF NOT EXISTS idx_markets_tags ON markets USING GIN (tags)`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			return nil, err
+		}
+	}
+
 	return db, nil
 }
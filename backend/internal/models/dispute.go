@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen     DisputeStatus = "open"
+	DisputeStatusUpheld   DisputeStatus = "upheld"
+	DisputeStatusRejected DisputeStatus = "rejected"
+)
+
+// Dispute is a bond-backed challenge to a proposed market resolution
+type Dispute struct {
+	ID          uint64          `gorm:"primaryKey" json:"id"`
+	MarketID    uint64          `gorm:"not null;uniqueIndex:idx_dispute_market_user" json:"market_id"`
+	UserAddress string          `gorm:"not null;size:42;uniqueIndex:idx_dispute_market_user" json:"user_address"`
+	Bond        decimal.Decimal `gorm:"not null;type:decimal(20,6)" json:"bond"`
+	Reason      string          `json:"reason"`
+	Payouts     datatypes.JSON  `json:"payouts"`
+	Status      DisputeStatus   `gorm:"not null;size:20;default:open" json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ResolvedAt  *time.Time      `json:"resolved_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyKey records a request made with an Idempotency-Key header so
+// that retries get the original response instead of repeating it. A
+// StatusCode of 0 means the first request is still being handled.
+type IdempotencyKey struct {
+	UserAddress string    `gorm:"primaryKey;size:42" json:"user_address"`
+	Key         string    `gorm:"primaryKey;size:64" json:"key"`
+	Fingerprint string    `gorm:"not null;size:64" json:"-"` // sha256 of method, path and body
+	StatusCode  int       `gorm:"not null;default:0" json:"status_code"`
+	Response    []byte    `json:"-"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
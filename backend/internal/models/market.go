@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -8,30 +9,107 @@ import (
 )
 
 type MarketStatus string
+type MarketType string
 
 const (
 	MarketStatusPending   MarketStatus = "pending"
 	MarketStatusActive    MarketStatus = "active"
+	MarketStatusProposed  MarketStatus = "proposed"
+	MarketStatusDisputed  MarketStatus = "disputed"
 	MarketStatusResolved  MarketStatus = "resolved"
 	MarketStatusCancelled MarketStatus = "cancelled"
+
+	MarketTypeCategorical MarketType = "categorical"
+	MarketTypeScalar      MarketType = "scalar"
+)
+
+// Scalar markets always have two outcomes: LONG pays more the higher the
+// resolved value, SHORT pays the remainder
+const (
+	ScalarOutcomeLong  uint8 = 1
+	ScalarOutcomeShort uint8 = 2
 )
 
+var ScalarOutcomes = []string{"LONG", "SHORT"}
+
 type Market struct {
-	ID              uint64         `gorm:"primaryKey" json:"id"`
-	ChainID         *uint64        `json:"chain_id"`
-	Question        string         `gorm:"not null" json:"question"`
-	Description     string         `json:"description"`
-	Outcomes        datatypes.JSON `gorm:"not null" json:"outcomes"`
-	EndTime         time.Time      `gorm:"not null" json:"end_time"`
-	ResolutionTime  time.Time      `gorm:"not null" json:"resolution_time"`
-	ResolvedOutcome *uint8         `json:"resolved_outcome"`
-	Status          MarketStatus   `gorm:"not null;default:pending" json:"status"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
+	ID              uint64           `gorm:"primaryKey" json:"id"`
+	ChainID         *uint64          `json:"chain_id"`
+	Question        string           `gorm:"not null" json:"question"`
+	Description     string           `json:"description"`
+	Category        string           `gorm:"size:64;index" json:"category"`
+	Tags            datatypes.JSON   `json:"tags"`
+	Type            MarketType       `gorm:"not null;size:20;default:categorical" json:"type"`
+	Outcomes        datatypes.JSON   `gorm:"not null" json:"outcomes"`
+	ScalarLow       *decimal.Decimal `gorm:"type:decimal(30,6)" json:"scalar_low,omitempty"`
+	ScalarHigh      *decimal.Decimal `gorm:"type:decimal(30,6)" json:"scalar_high,omitempty"`
+	EndTime         time.Time        `gorm:"not null" json:"end_time"`
+	ResolutionTime  time.Time        `gorm:"not null" json:"resolution_time"`
+	ResolvedOutcome *uint8           `json:"resolved_outcome"`
+	ResolvedValue   *decimal.Decimal `gorm:"type:decimal(30,6)" json:"resolved_value,omitempty"`
+	Payouts         datatypes.JSON   `json:"payouts"`
+	ProposedAt      *time.Time       `json:"proposed_at,omitempty"`
+	DisputeDeadline *time.Time       `json:"dispute_deadline,omitempty"`
+	Oracle          datatypes.JSON   `json:"oracle,omitempty"`
+	Status          MarketStatus     `gorm:"not null;default:pending" json:"status"`
+	Volume          decimal.Decimal  `gorm:"not null;type:decimal(30,6);default:0;index" json:"-"` // traded notional, maintained by settlement.RecordTrade for sorting
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// NormalizeTags lowercases and trims tags, dropping empties and duplicates
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// ScalarPayouts returns the per-share LONG and SHORT payouts for a resolved
+// value. Values outside the range are clamped to its bounds.
+func (m *Market) ScalarPayouts(value decimal.Decimal) (long, short decimal.Decimal) {
+	low, high := *m.ScalarLow, *m.ScalarHigh
+	if value.LessThan(low) {
+		value = low
+	}
+	if value.GreaterThan(high) {
+		value = high
+	}
+
+	long = value.Sub(low).DivRound(high.Sub(low), 8)
+	short = decimal.NewFromInt(1).Sub(long)
+	return long, short
 }
 
+// MarketWithStats is a market with its trading statistics. Volumes are
+// notional (price × quantity). LastPrice and PriceChange24h are those of
+// the first outcome, which is the "Yes" price of a binary market.
 type MarketWithStats struct {
 	Market
-	TotalVolume decimal.Decimal `json:"total_volume"`
-	LastPrice   decimal.Decimal `json:"last_price"`
+	TotalVolume    decimal.Decimal `json:"total_volume"`
+	Volume24h      decimal.Decimal `json:"volume_24h"`
+	LastPrice      decimal.Decimal `json:"last_price"`
+	PriceChange24h decimal.Decimal `json:"price_change_24h"`
+	OpenInterest   decimal.Decimal `json:"open_interest"`
+	OutcomeStats   []OutcomeStats  `json:"outcome_stats"`
+}
+
+// OutcomeStats are the trading statistics of one outcome. OpenInterest is
+// the number of outstanding shares held long.
+type OutcomeStats struct {
+	Outcome        uint8            `json:"outcome"`
+	TotalVolume    decimal.Decimal  `json:"total_volume"`
+	Volume24h      decimal.Decimal  `json:"volume_24h"`
+	LastPrice      decimal.Decimal  `json:"last_price"`
+	PriceChange24h decimal.Decimal  `json:"price_change_24h"`
+	BestBid        *decimal.Decimal `json:"best_bid"`
+	BestAsk        *decimal.Decimal `json:"best_ask"`
+	OpenInterest   decimal.Decimal  `json:"open_interest"`
 }
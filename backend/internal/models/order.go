@@ -22,17 +22,52 @@ const (
 type Order struct {
 	ID             uint64          `gorm:"primaryKey" json:"id"`
 	MarketID       uint64          `gorm:"not null;index" json:"market_id"`
-	UserAddress    string          `gorm:"not null;size:42;index" json:"user_address"`
+	UserAddress    string          `gorm:"not null;size:42;index;index:idx_order_user_created,priority:1;uniqueIndex:idx_order_user_nonce,priority:1;uniqueIndex:idx_order_user_client,priority:1" json:"user_address"`
 	Outcome        uint8           `gorm:"not null" json:"outcome"`
 	Side           OrderSide       `gorm:"not null;size:4" json:"side"`
 	Price          decimal.Decimal `gorm:"not null;type:decimal(10,4)" json:"price"`
 	Quantity       decimal.Decimal `gorm:"not null;type:decimal(20,6)" json:"quantity"`
 	FilledQuantity decimal.Decimal `gorm:"not null;type:decimal(20,6);default:0" json:"filled_quantity"`
 	Status         OrderStatus     `gorm:"not null;size:20;default:open" json:"status"`
-	CreatedAt      time.Time       `json:"created_at"`
-	UpdatedAt      time.Time       `json:"updated_at"`
+	// Shares of a sell order beyond what the user held when placing it.
+	// 1 − price is locked for each of them to back the short.
+	Uncovered decimal.Decimal `gorm:"not null;type:decimal(20,6);default:0" json:"uncovered"`
+	// EIP-712 authorisation by the user: a uint256 nonce unique per user,
+	// an expiry as unix time (0 for none) and the signature
+	Nonce     *string `gorm:"size:78;uniqueIndex:idx_order_user_nonce,priority:2" json:"nonce"`
+	Expiry    int64   `gorm:"not null;default:0" json:"expiry"`
+	Signature string  `gorm:"size:132" json:"signature"`
+	// Optional label the client chose, unique per user
+	ClientOrderID *string   `gorm:"size:64;uniqueIndex:idx_order_user_client,priority:2" json:"client_order_id"`
+	CreatedAt     time.Time `gorm:"index:idx_order_user_created,priority:2" json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 func (o *Order) RemainingQuantity() decimal.Decimal {
 	return o.Quantity.Sub(o.FilledQuantity)
 }
+
+// LockedFor is the collateral backing the order's first qty shares: the
+// price of each share for buys and 1 − price of each uncovered share for
+// sells, which sell the shares the user holds first
+func (o *Order) LockedFor(qty decimal.Decimal) decimal.Decimal {
+	if o.Side == OrderSideBuy {
+		return qty.Mul(o.Price)
+	}
+	short := qty.Sub(o.Quantity.Sub(o.Uncovered))
+	if !short.IsPositive() {
+		return decimal.Zero
+	}
+	return short.Mul(decimal.NewFromInt(1).Sub(o.Price))
+}
+
+// UnfilledLocked is the collateral backing the unfilled quantity, released
+// when the order is cancelled
+func (o *Order) UnfilledLocked() decimal.Decimal {
+	return o.LockedFor(o.Quantity).Sub(o.LockedFor(o.FilledQuantity))
+}
+
+// Expired reports whether the order's signed expiry has passed at now
+func (o *Order) Expired(now time.Time) bool {
+	return o.Expiry > 0 && now.Unix() >= o.Expiry
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Position is a user's net outcome share holding in a market. Shares is
+// negative for net sellers; Cost is the net amount paid for the shares
+// (negative when the user has received more than they paid).
+type Position struct {
+	UserAddress string          `gorm:"primaryKey;size:42" json:"user_address"`
+	MarketID    uint64          `gorm:"primaryKey;index" json:"market_id"`
+	Outcome     uint8           `gorm:"primaryKey" json:"outcome"`
+	Shares      decimal.Decimal `gorm:"not null;type:decimal(20,6);default:0" json:"shares"`
+	Cost        decimal.Decimal `gorm:"not null;type:decimal(20,6);default:0" json:"cost"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
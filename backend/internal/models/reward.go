@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RewardPool configures the liquidity reward budget paid out per epoch for a market
+type RewardPool struct {
+	MarketID       uint64          `gorm:"primaryKey" json:"market_id"`
+	AmountPerEpoch decimal.Decimal `gorm:"not null;type:decimal(20,6);default:0" json:"amount_per_epoch"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// MakerReward accumulates a maker's liquidity score for one market and epoch
+type MakerReward struct {
+	ID          uint64          `gorm:"primaryKey" json:"id"`
+	EpochStart  time.Time       `gorm:"not null;uniqueIndex:idx_maker_reward" json:"epoch_start"`
+	MarketID    uint64          `gorm:"not null;uniqueIndex:idx_maker_reward" json:"market_id"`
+	UserAddress string          `gorm:"not null;size:42;uniqueIndex:idx_maker_reward;index" json:"user_address"`
+	Score       decimal.Decimal `gorm:"not null;type:decimal(30,6);default:0" json:"score"`
+	Amount      decimal.Decimal `gorm:"not null;type:decimal(20,6);default:0" json:"amount"`
+	Paid        bool            `gorm:"not null;default:false" json:"paid"`
+	PaidAt      *time.Time      `json:"paid_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
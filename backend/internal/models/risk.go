@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RiskLimit overrides the global risk limits for one user. A nil field
+// falls back to the global default; zero means unlimited.
+type RiskLimit struct {
+	UserAddress       string           `gorm:"primaryKey;size:42" json:"user_address"`
+	MaxOpenOrders     *int             `json:"max_open_orders"`
+	MaxPosition       *decimal.Decimal `gorm:"type:decimal(20,6)" json:"max_position"`
+	MaxMarketNotional *decimal.Decimal `gorm:"type:decimal(20,6)" json:"max_market_notional"`
+	MaxDailyVolume    *decimal.Decimal `gorm:"type:decimal(20,6)" json:"max_daily_volume"`
+	UpdatedBy         string           `gorm:"size:64" json:"updated_by"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
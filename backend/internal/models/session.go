@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// AuthNonce is a Sign-In with Ethereum nonce handed out by the server. It
+// is deleted when a sign-in consumes it.
+type AuthNonce struct {
+	Nonce     string    `gorm:"primaryKey;size:32" json:"nonce"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RefreshToken is a wallet session's refresh token. Only the SHA-256 of
+// the token is stored; each refresh revokes it and issues a new one.
+type RefreshToken struct {
+	ID          uint64     `gorm:"primaryKey" json:"id"`
+	TokenHash   string     `gorm:"not null;size:64;uniqueIndex" json:"-"`
+	UserAddress string     `gorm:"not null;size:42;index" json:"user_address"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,284 @@
+package admins
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prediction-market/backend/internal/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// tokenType marks admin access tokens so that wallet session tokens signed
+// with the same secret are not accepted as admin tokens and vice versa
+const tokenType = "admin"
+
+// minPasswordLength is the shortest password an account may be given
+const minPasswordLength = 12
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrAccountNotFound    = errors.New("admin account not found")
+	ErrUsernameTaken      = errors.New("username already taken")
+	ErrInvalidToken       = errors.New("invalid token")
+)
+
+// Claims are the claims of an admin access token. The subject is the
+// account ID.
+type Claims struct {
+	Type     string   `json:"typ"`
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// HasRole reports whether the token grants role. Super admins hold every
+// role.
+func (c *Claims) HasRole(role models.AdminRole) bool {
+	for _, r := range c.Roles {
+		if r == string(role) || r == string(models.AdminRoleSuperAdmin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Service manages admin accounts and issues their access tokens
+type Service struct {
+	db       *gorm.DB
+	secret   []byte
+	tokenTTL time.Duration
+}
+
+// Token is an issued admin access token
+type Token struct {
+	AccessToken string              `json:"access_token"`
+	ExpiresAt   time.Time           `json:"expires_at"`
+	Account     models.AdminAccount `json:"account"`
+}
+
+// NewService creates a new admins Service
+func NewService(db *gorm.DB, secret string, tokenTTL time.Duration) *Service {
+	return &Service{db: db, secret: []byte(secret), tokenTTL: tokenTTL}
+}
+
+// Bootstrap creates a super admin when there are no admin accounts yet, so
+// a fresh deployment can sign in. It does nothing once accounts exist.
+func (s *Service) Bootstrap(username, password string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.AdminAccount{}).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if _, err := s.Create(username, password, []string{string(models.AdminRoleSuperAdmin)}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Login checks a username and password and issues an access token
+func (s *Service) Login(username, password string) (*Token, error) {
+	var account models.AdminAccount
+	if err := s.db.Where("username = ?", username).First(&account).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// Spend the same time as a wrong password so usernames cannot
+			// be probed by timing
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(password)) != nil || account.Disabled {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&account).Update("last_login_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(s.tokenTTL)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Type:     tokenType,
+		Username: account.Username,
+		Roles:    account.RoleList(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(account.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Token{AccessToken: token, ExpiresAt: expiresAt, Account: account}, nil
+}
+
+// Create adds an admin account
+func (s *Service) Create(username, password string, roles []string) (*models.AdminAccount, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, errors.New("username is required")
+	}
+	normalized, err := normalizeRoles(roles)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	var taken int64
+	if err := s.db.Model(&models.AdminAccount{}).Where("username = ?", username).Count(&taken).Error; err != nil {
+		return nil, err
+	}
+	if taken > 0 {
+		return nil, ErrUsernameTaken
+	}
+
+	account := &models.AdminAccount{Username: username, PasswordHash: hash, Roles: normalized}
+	if err := s.db.Create(account).Error; err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// List returns every admin account
+func (s *Service) List() ([]models.AdminAccount, error) {
+	accounts := make([]models.AdminAccount, 0)
+	err := s.db.Order("id").Find(&accounts).Error
+	return accounts, err
+}
+
+// Update changes an account's roles, password or disabled flag; nil
+// arguments are left unchanged. Tokens already issued pick up the change
+// on their next request.
+func (s *Service) Update(id uint64, roles []string, password *string, disabled *bool) (*models.AdminAccount, error) {
+	var account models.AdminAccount
+	if err := s.db.First(&account, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if roles != nil {
+		normalized, err := normalizeRoles(roles)
+		if err != nil {
+			return nil, err
+		}
+		updates["roles"] = normalized
+	}
+	if password != nil {
+		hash, err := hashPassword(*password)
+		if err != nil {
+			return nil, err
+		}
+		updates["password_hash"] = hash
+	}
+	if disabled != nil {
+		updates["disabled"] = *disabled
+	}
+	if len(updates) == 0 {
+		return &account, nil
+	}
+
+	if err := s.db.Model(&account).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&account, id).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Authenticate validates an admin access token and checks it against the
+// current account, so disabling an account or changing its roles takes
+// effect on the next request. The returned claims carry the account's
+// current username and roles.
+func (s *Service) Authenticate(token string) (*Claims, error) {
+	claims, err := ParseToken(string(s.secret), token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var account models.AdminAccount
+	if err := s.db.First(&account, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if account.Disabled {
+		return nil, ErrInvalidToken
+	}
+
+	claims.Username = account.Username
+	claims.Roles = account.RoleList()
+	return claims, nil
+}
+
+// ParseToken validates an admin access token and returns its claims
+func ParseToken(secret, token string) (*Claims, error) {
+	var claims Claims
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if claims.Type != tokenType || claims.Subject == "" {
+		return nil, errors.New("not an admin token")
+	}
+	return &claims, nil
+}
+
+// dummyHash is compared against when a username does not exist
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func normalizeRoles(roles []string) (string, error) {
+	if len(roles) == 0 {
+		return "", errors.New("at least one role is required")
+	}
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(roles))
+	for _, role := range roles {
+		known := false
+		for _, r := range models.AdminRoles {
+			if role == string(r) {
+				known = true
+			}
+		}
+		if !known {
+			return "", fmt.Errorf("unknown role %q", role)
+		}
+		if !seen[role] {
+			seen[role] = true
+			normalized = append(normalized, role)
+		}
+	}
+	return strings.Join(normalized, ","), nil
+}
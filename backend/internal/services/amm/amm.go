@@ -0,0 +1,279 @@
+package amm
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/settlement"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Service routes orders against per-market LMSR pools
+type Service struct {
+	db       *gorm.DB
+	address  string
+	treasury string
+}
+
+// Quote is the current AMM price of every outcome in a market
+type Quote struct {
+	MarketID  uint64            `json:"market_id"`
+	Liquidity decimal.Decimal   `json:"liquidity"`
+	Prices    []decimal.Decimal `json:"prices"`
+}
+
+// NewService creates a new AMM Service whose trades and funding are booked
+// against the given platform account address. Pools are funded from the
+// treasury account.
+func NewService(db *gorm.DB, address, treasury string) *Service {
+	return &Service{db: db, address: address, treasury: treasury}
+}
+
+// Address returns the account the AMM trades as
+func (s *Service) Address() string {
+	return s.address
+}
+
+// loadMaker decodes a pool into an LMSR instance
+func loadMaker(pool *models.AMMPool) (*LMSR, error) {
+	var quantities []decimal.Decimal
+	if err := json.Unmarshal(pool.Quantities, &quantities); err != nil {
+		return nil, err
+	}
+	q := make([]float64, len(quantities))
+	for i, v := range quantities {
+		q[i] = v.InexactFloat64()
+	}
+	return &LMSR{B: pool.Liquidity.InexactFloat64(), Q: q}, nil
+}
+
+// CreatePool opens an LMSR pool for a market and moves the worst-case loss
+// b · ln(n) from the treasury to the AMM account. It returns
+// settlement.ErrInsufficientFunds if the treasury cannot cover it.
+func (s *Service) CreatePool(tx *gorm.DB, marketID uint64, liquidity decimal.Decimal, outcomes int) (*models.AMMPool, error) {
+	if liquidity.LessThanOrEqual(decimal.Zero) {
+		return nil, errors.New("liquidity must be positive")
+	}
+	if outcomes < 2 {
+		return nil, errors.New("amm requires at least two outcomes")
+	}
+
+	quantities, err := json.Marshal(make([]decimal.Decimal, outcomes))
+	if err != nil {
+		return nil, err
+	}
+
+	maker := &LMSR{B: liquidity.InexactFloat64(), Q: make([]float64, outcomes)}
+	funding := decimal.NewFromFloat(maker.MaxLoss()).RoundUp(6)
+
+	pool := &models.AMMPool{
+		MarketID:   marketID,
+		Liquidity:  liquidity,
+		Quantities: datatypes.JSON(quantities),
+		Funding:    funding,
+		Enabled:    true,
+	}
+	if err := tx.Create(pool).Error; err != nil {
+		return nil, err
+	}
+
+	var balance models.UserBalance
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").
+		FirstOrCreate(&balance, models.UserBalance{UserAddress: s.address}).Error; err != nil {
+		return nil, err
+	}
+	balance.Available = balance.Available.Add(funding)
+	if err := tx.Save(&balance).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(&models.BalanceLog{
+		UserAddress:  s.address,
+		ChangeType:   "amm_funding",
+		Amount:       funding,
+		BalanceAfter: balance.Available,
+		ReferenceID:  &marketID,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if _, err := settlement.Debit(tx, s.treasury, funding, "amm_funding", &marketID); err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// GetQuote returns the AMM prices for a market, or nil if it has no pool
+func (s *Service) GetQuote(marketID uint64) (*Quote, error) {
+	var pool models.AMMPool
+	if err := s.db.First(&pool, "market_id = ? AND enabled = ?", marketID, true).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	maker, err := loadMaker(&pool)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &Quote{MarketID: marketID, Liquidity: pool.Liquidity}
+	for _, p := range maker.Prices() {
+		quote.Prices = append(quote.Prices, decimal.NewFromFloat(p).Round(4))
+	}
+	return quote, nil
+}
+
+// FillBuy buys shares from the market's pool for a taker buy order, as long
+// as the AMM is cheaper than both the order's limit and the best resting ask
+// (if hasAsk). It stops at the price where the book becomes competitive so
+// the remainder can be matched there. The order's filled quantity and status
+// are updated in place. Returns nil if the AMM did not trade.
+func (s *Service) FillBuy(tx *gorm.DB, order *models.Order, bestAsk decimal.Decimal, hasAsk bool) (*models.Trade, error) {
+	if order.Side != models.OrderSideBuy {
+		return nil, nil
+	}
+
+	target := order.Price
+	if hasAsk && bestAsk.LessThan(target) {
+		target = bestAsk
+	}
+
+	return s.fill(tx, order, func(maker *LMSR, outcome int) (decimal.Decimal, decimal.Decimal) {
+		shares := decimal.NewFromFloat(maker.SharesToPrice(outcome, target.InexactFloat64())).Truncate(6)
+		shares = decimal.Min(shares, order.RemainingQuantity())
+		if shares.LessThanOrEqual(decimal.Zero) {
+			return decimal.Zero, decimal.Zero
+		}
+
+		cost := maker.BuyCost(outcome, shares.InexactFloat64())
+		if math.IsNaN(cost) || math.IsInf(cost, 0) {
+			return decimal.Zero, decimal.Zero
+		}
+
+		// Round the average price against the taker; it stays within target
+		// because the marginal price only reaches target at the last share
+		price := decimal.NewFromFloat(cost).Div(shares).RoundUp(4)
+		if price.GreaterThan(target) || price.LessThanOrEqual(decimal.Zero) {
+			return decimal.Zero, decimal.Zero
+		}
+		return shares, price
+	})
+}
+
+// FillSell sells shares into the market's pool for a taker sell order, as
+// long as the AMM pays more than both the order's limit and the best resting
+// bid (if hasBid). It mirrors FillBuy: the AMM stops buying at the price where
+// the book becomes competitive and the order is updated in place. Sells are
+// collateralised, so the pool never pays for shares the seller cannot
+// deliver at settlement.
+func (s *Service) FillSell(tx *gorm.DB, order *models.Order, bestBid decimal.Decimal, hasBid bool) (*models.Trade, error) {
+	if order.Side != models.OrderSideSell {
+		return nil, nil
+	}
+
+	target := order.Price
+	if hasBid && bestBid.GreaterThan(target) {
+		target = bestBid
+	}
+
+	return s.fill(tx, order, func(maker *LMSR, outcome int) (decimal.Decimal, decimal.Decimal) {
+		shares := decimal.NewFromFloat(maker.SharesToSellToPrice(outcome, target.InexactFloat64())).Truncate(6)
+		shares = decimal.Min(shares, order.RemainingQuantity())
+		if shares.LessThanOrEqual(decimal.Zero) {
+			return decimal.Zero, decimal.Zero
+		}
+
+		proceeds := -maker.BuyCost(outcome, -shares.InexactFloat64())
+		if math.IsNaN(proceeds) || math.IsInf(proceeds, 0) {
+			return decimal.Zero, decimal.Zero
+		}
+
+		// Round the average price against the taker; it stays within target
+		// because the marginal price only falls to target at the last share
+		price := decimal.NewFromFloat(proceeds).Div(shares).Truncate(4)
+		if price.LessThan(target) || price.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+			return decimal.Zero, decimal.Zero
+		}
+		return shares, price
+	})
+}
+
+// fill trades an order against the market's pool. size returns how many
+// shares the pool takes from or gives to the order and at what average
+// price, or zero shares if it does not trade.
+func (s *Service) fill(tx *gorm.DB, order *models.Order, size func(maker *LMSR, outcome int) (decimal.Decimal, decimal.Decimal)) (*models.Trade, error) {
+	var pool models.AMMPool
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").
+		First(&pool, "market_id = ? AND enabled = ?", order.MarketID, true).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	maker, err := loadMaker(&pool)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := int(order.Outcome) - 1
+	if outcome < 0 || outcome >= len(maker.Q) {
+		return nil, errors.New("invalid outcome")
+	}
+
+	shares, price := size(maker, outcome)
+	if shares.IsZero() {
+		return nil, nil
+	}
+
+	// The pool's outstanding quantity grows when it sells and shrinks when
+	// it buys
+	delta := shares
+	if order.Side == models.OrderSideSell {
+		delta = shares.Neg()
+	}
+
+	var quantities []decimal.Decimal
+	if err := json.Unmarshal(pool.Quantities, &quantities); err != nil {
+		return nil, err
+	}
+	quantities[outcome] = quantities[outcome].Add(delta)
+	encoded, err := json.Marshal(quantities)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&pool).Update("quantities", datatypes.JSON(encoded)).Error; err != nil {
+		return nil, err
+	}
+
+	trade := &models.Trade{
+		MarketID:     order.MarketID,
+		TakerOrderID: order.ID,
+		MakerAddress: s.address,
+		TakerAddress: order.UserAddress,
+		Outcome:      order.Outcome,
+		Price:        price,
+		Quantity:     shares,
+		CreatedAt:    time.Now(),
+	}
+	if err := tx.Create(trade).Error; err != nil {
+		return nil, err
+	}
+
+	order.FilledQuantity = order.FilledQuantity.Add(shares)
+	if order.RemainingQuantity().IsZero() {
+		order.Status = models.OrderStatusFilled
+	} else {
+		order.Status = models.OrderStatusPartial
+	}
+
+	return trade, nil
+}
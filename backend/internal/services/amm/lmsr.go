@@ -0,0 +1,98 @@
+package amm
+
+import "math"
+
+// LMSR implements Hanson's Logarithmic Market Scoring Rule with liquidity
+// parameter B over outstanding share quantities Q (one per outcome)
+type LMSR struct {
+	B float64
+	Q []float64
+}
+
+// logSumExp computes ln(Σ exp(x_i)) without overflowing
+func logSumExp(xs []float64) float64 {
+	max := math.Inf(-1)
+	for _, x := range xs {
+		if x > max {
+			max = x
+		}
+	}
+	if math.IsInf(max, -1) {
+		return max
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += math.Exp(x - max)
+	}
+	return max + math.Log(sum)
+}
+
+// scaled returns q_i / b for every outcome, skipping outcome skip if >= 0
+func (m *LMSR) scaled(skip int) []float64 {
+	xs := make([]float64, 0, len(m.Q))
+	for i, q := range m.Q {
+		if i == skip {
+			continue
+		}
+		xs = append(xs, q/m.B)
+	}
+	return xs
+}
+
+// Cost returns C(q) = b · ln(Σ exp(q_i / b))
+func (m *LMSR) Cost() float64 {
+	return m.B * logSumExp(m.scaled(-1))
+}
+
+// Price returns the instantaneous price of an outcome
+func (m *LMSR) Price(outcome int) float64 {
+	return math.Exp(m.Q[outcome]/m.B - logSumExp(m.scaled(-1)))
+}
+
+// Prices returns the instantaneous price of every outcome, summing to 1
+func (m *LMSR) Prices() []float64 {
+	lse := logSumExp(m.scaled(-1))
+	prices := make([]float64, len(m.Q))
+	for i, q := range m.Q {
+		prices[i] = math.Exp(q/m.B - lse)
+	}
+	return prices
+}
+
+// BuyCost returns the cost of buying shares of an outcome
+func (m *LMSR) BuyCost(outcome int, shares float64) float64 {
+	before := m.Cost()
+	m.Q[outcome] += shares
+	after := m.Cost()
+	m.Q[outcome] -= shares
+	return after - before
+}
+
+// SharesToPrice returns how many shares of an outcome must be bought to move
+// its price up to target. It returns 0 if the price is already at or above target.
+func (m *LMSR) SharesToPrice(outcome int, target float64) float64 {
+	if target <= 0 || target >= 1 || m.Price(outcome) >= target {
+		return 0
+	}
+	// Solve exp(q'/b) / (exp(q'/b) + S) = target for q', where S sums the other outcomes
+	lnOthers := logSumExp(m.scaled(outcome))
+	q := m.B * (math.Log(target) - math.Log(1-target) + lnOthers)
+	return math.Max(0, q-m.Q[outcome])
+}
+
+// SharesToSellToPrice returns how many shares of an outcome must be sold to
+// move its price down to target. It returns 0 if the price is already at or
+// below target.
+func (m *LMSR) SharesToSellToPrice(outcome int, target float64) float64 {
+	if target <= 0 || target >= 1 || m.Price(outcome) <= target {
+		return 0
+	}
+	lnOthers := logSumExp(m.scaled(outcome))
+	q := m.B * (math.Log(target) - math.Log(1-target) + lnOthers)
+	return math.Max(0, m.Q[outcome]-q)
+}
+
+// MaxLoss returns the worst-case subsidy b · ln(n) the market maker can lose
+func (m *LMSR) MaxLoss() float64 {
+	return m.B * math.Log(float64(len(m.Q)))
+}
@@ -0,0 +1,267 @@
+package apikeys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Scopes an API key can be granted
+const (
+	ScopeRead   = "read"   // read the user's orders, rewards and private stream
+	ScopeTrade  = "trade"  // place orders and cancel them
+	ScopeCancel = "cancel" // cancel orders only
+)
+
+// grants lists the permissions each scope carries
+var grants = map[string][]string{
+	ScopeRead:   {ScopeRead},
+	ScopeTrade:  {ScopeTrade, ScopeCancel},
+	ScopeCancel: {ScopeCancel},
+}
+
+// maxKeysPerUser caps the live keys a user may hold
+const maxKeysPerUser = 20
+
+var (
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrTooManyKeys  = fmt.Errorf("at most %d active api keys per user", maxKeysPerUser)
+	ErrKeyNotFound  = errors.New("api key not found")
+	ErrIPNotAllowed = errors.New("ip address not allowed for this api key")
+)
+
+// Service issues and checks API keys. Secrets are sealed with AES-GCM under
+// a key derived from the configured encryption key.
+type Service struct {
+	db   *gorm.DB
+	aead cipher.AEAD
+}
+
+// NewService creates a new apikeys Service
+func NewService(db *gorm.DB, encryptionKey string) (*Service, error) {
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{db: db, aead: aead}, nil
+}
+
+// CreateRequest describes a new key
+type CreateRequest struct {
+	Label      string
+	Scopes     []string
+	AllowedIPs []string
+	ExpiresAt  *time.Time
+}
+
+// Create issues a key for a user and returns it with its secret. The
+// secret cannot be retrieved again.
+func (s *Service) Create(userAddress string, req CreateRequest) (*models.APIKey, string, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, entry := range req.AllowedIPs {
+		if _, err := parseAllowed(entry); err != nil {
+			return nil, "", err
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "", errors.New("expiry must be in the future")
+	}
+
+	var active int64
+	if err := s.active(s.db.Model(&models.APIKey{}).Where("user_address = ?", userAddress)).
+		Count(&active).Error; err != nil {
+		return nil, "", err
+	}
+	if active >= maxKeysPerUser {
+		return nil, "", ErrTooManyKeys
+	}
+
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &models.APIKey{
+		UserAddress:     userAddress,
+		Key:             id,
+		EncryptedSecret: sealed,
+		Label:           req.Label,
+		Scopes:          strings.Join(scopes, ","),
+		AllowedIPs:      strings.Join(req.AllowedIPs, ","),
+		ExpiresAt:       req.ExpiresAt,
+	}
+	if err := s.db.Create(key).Error; err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// List returns a user's keys that are not revoked or expired
+func (s *Service) List(userAddress string) ([]models.APIKey, error) {
+	keys := make([]models.APIKey, 0)
+	err := s.active(s.db.Where("user_address = ?", userAddress)).
+		Order("id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Revoke revokes one of a user's keys
+func (s *Service) Revoke(userAddress string, id uint64) error {
+	result := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_address = ? AND revoked_at IS NULL", id, userAddress).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate looks up a live key used from ip and returns it with its
+// secret
+func (s *Service) Authenticate(keyID, ip string) (*models.APIKey, string, error) {
+	var key models.APIKey
+	if err := s.active(s.db.Where("key = ?", keyID)).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, "", ErrInvalidKey
+		}
+		return nil, "", err
+	}
+	if !ipAllowed(key.AllowedIPs, ip) {
+		return nil, "", ErrIPNotAllowed
+	}
+
+	secret, err := s.open(key.EncryptedSecret)
+	if err != nil {
+		return nil, "", err
+	}
+	return &key, secret, nil
+}
+
+// Touch records that a key was used
+func (s *Service) Touch(key *models.APIKey) error {
+	return s.db.Model(key).Update("last_used_at", time.Now()).Error
+}
+
+// HasScope reports whether a comma-separated scope list grants scope
+func HasScope(scopes, scope string) bool {
+	for _, granted := range strings.Split(scopes, ",") {
+		for _, g := range grants[granted] {
+			if g == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Service) active(query *gorm.DB) *gorm.DB {
+	return query.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+}
+
+func (s *Service) seal(secret string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(s.aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func (s *Service) open(sealed string) (string, error) {
+	raw, err := hex.DecodeString(sealed)
+	if err != nil || len(raw) < s.aead.NonceSize() {
+		return "", errors.New("corrupted api key secret")
+	}
+	nonce, ciphertext := raw[:s.aead.NonceSize()], raw[s.aead.NonceSize():]
+	secret, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("api key secret cannot be decrypted")
+	}
+	return string(secret), nil
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if _, ok := grants[scope]; !ok {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// parseAllowed parses an allowlist entry, a single IP or a CIDR
+func parseAllowed(entry string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		return network, nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip or cidr %q", entry)
+	}
+	bits := 8 * len(ip)
+	if v4 := ip.To4(); v4 != nil {
+		ip, bits = v4, 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func ipAllowed(allowed, ip string) bool {
+	if allowed == "" {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range strings.Split(allowed, ",") {
+		if network, err := parseAllowed(entry); err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
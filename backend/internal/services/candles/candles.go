@@ -0,0 +1,99 @@
+package candles
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Intervals are the supported candle widths, keyed by their API name
+var Intervals = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// bucketStart returns the start of the interval containing t
+func bucketStart(t time.Time, width time.Duration) time.Time {
+	return t.UTC().Truncate(width)
+}
+
+// RecordTrade folds a trade into the candle of every interval. It runs in
+// the transaction that creates the trade so candles never miss a fill.
+func RecordTrade(tx *gorm.DB, trade *models.Trade) error {
+	for name, width := range Intervals {
+		candle := models.Candle{
+			MarketID:  trade.MarketID,
+			Outcome:   trade.Outcome,
+			Interval:  name,
+			StartTime: bucketStart(trade.CreatedAt, width),
+			Open:      trade.Price,
+			High:      trade.Price,
+			Low:       trade.Price,
+			Close:     trade.Price,
+			Volume:    trade.Quantity,
+			Trades:    1,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "market_id"}, {Name: "outcome"}, {Name: "interval"}, {Name: "start_time"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"high":   gorm.Expr("GREATEST(candles.high, EXCLUDED.high)"),
+				"low":    gorm.Expr("LEAST(candles.low, EXCLUDED.low)"),
+				"close":  gorm.Expr("EXCLUDED.close"),
+				"volume": gorm.Expr("candles.volume + EXCLUDED.volume"),
+				"trades": gorm.Expr("candles.trades + 1"),
+			}),
+		}).Create(&candle).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backfill rebuilds the candles of an interval from the trades table. It
+// runs at startup for intervals that have no candles yet, so trades made
+// before the table existed are charted too.
+func Backfill(db *gorm.DB) error {
+	for name, width := range Intervals {
+		var exists bool
+		if err := db.Raw(`SELECT EXISTS (SELECT 1 FROM candles WHERE "interval" = ?)`, name).
+			Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		seconds := int64(width / time.Second)
+		bucket := fmt.Sprintf("to_timestamp(floor(extract(epoch from created_at) / %d) * %d)", seconds, seconds)
+		if err := db.Exec(`INSERT INTO candles (market_id, outcome, "interval", start_time, open, high, low, close, volume, trades)
+			SELECT market_id, outcome, ?, `+bucket+`,
+				(array_agg(price ORDER BY created_at, id))[1],
+				MAX(price),
+				MIN(price),
+				(array_agg(price ORDER BY created_at DESC, id DESC))[1],
+				SUM(quantity),
+				COUNT(*)
+			FROM trades
+			GROUP BY market_id, outcome, `+bucket+`
+			ON CONFLICT DO NOTHING`, name).Error; err != nil {
+			return fmt.Errorf("backfill %s candles: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Query returns the candles of an outcome that start in [from, to), oldest
+// first. Intervals without trades are omitted.
+func Query(db *gorm.DB, marketID uint64, outcome uint8, interval string, from, to time.Time) ([]models.Candle, error) {
+	candles := make([]models.Candle, 0)
+	err := db.Where(`market_id = ? AND outcome = ? AND "interval" = ? AND start_time >= ? AND start_time < ?`,
+		marketID, outcome, interval, bucketStart(from, Intervals[interval]), to).
+		Order("start_time").
+		Find(&candles).Error
+	return candles, err
+}
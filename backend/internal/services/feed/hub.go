@@ -0,0 +1,253 @@
+package feed
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/orderbook"
+)
+
+// Message types
+const (
+	TypeSnapshot = "snapshot"
+	TypeDelta    = "delta"
+	TypeTrade    = "trade"
+	TypeStatus   = "status"
+	TypeOrder    = "order"
+	TypeFill     = "fill"
+	TypeBalance  = "balance"
+	TypeTop      = "top"
+)
+
+// subscriberBuffer is how many messages a subscriber may fall behind
+// before it is dropped
+const subscriberBuffer = 256
+
+// Message is one update on a channel
+type Message struct {
+	Channel string      `json:"channel"`
+	Type    string      `json:"type"`
+	Data    interface{} `json:"data"`
+}
+
+// BookChannel is the channel of an outcome's order book deltas
+func BookChannel(marketID uint64, outcome uint8) string {
+	return fmt.Sprintf("book:%d:%d", marketID, outcome)
+}
+
+// TradesChannel is the channel of a market's public trades
+func TradesChannel(marketID uint64) string {
+	return fmt.Sprintf("trades:%d", marketID)
+}
+
+// StatusChannel is the channel of a market's status changes
+func StatusChannel(marketID uint64) string {
+	return fmt.Sprintf("status:%d", marketID)
+}
+
+// UserChannel is the private channel of a user's orders, fills and
+// balance. It is only reachable through the authenticated stream.
+func UserChannel(address string) string {
+	return "user:" + address
+}
+
+// StatusUpdate is published when a market changes status
+type StatusUpdate struct {
+	MarketID        uint64              `json:"market_id"`
+	Status          models.MarketStatus `json:"status"`
+	ResolvedOutcome *uint8              `json:"resolved_outcome,omitempty"`
+}
+
+// Fill is a trade from the point of view of one of its parties
+type Fill struct {
+	models.Trade
+	OrderID   uint64           `json:"order_id"`
+	Side      models.OrderSide `json:"side"`
+	Liquidity string           `json:"liquidity"` // maker or taker
+}
+
+// Subscriber receives the messages of the channels it is subscribed to on
+// C. C is closed when the subscriber is removed, including when it falls
+// too far behind.
+type Subscriber struct {
+	C        chan Message
+	channels map[string]bool
+}
+
+// Hub fans out market data to subscribers and journals each outcome's
+// recent trades and top of book for stream clients that reconnect
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscriber]bool
+	journals    map[journalKey]*journal
+}
+
+// NewHub creates a new Hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[*Subscriber]bool),
+		journals:    make(map[journalKey]*journal),
+	}
+}
+
+// NewSubscriber creates a subscriber with no channels
+func (h *Hub) NewSubscriber() *Subscriber {
+	return &Subscriber{
+		C:        make(chan Message, subscriberBuffer),
+		channels: make(map[string]bool),
+	}
+}
+
+// Subscribe adds a channel to a subscriber
+func (h *Hub) Subscribe(s *Subscriber, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribe(s, channel)
+}
+
+func (h *Hub) subscribe(s *Subscriber, channel string) {
+	if s.channels == nil {
+		// Already removed
+		return
+	}
+	if h.subscribers[channel] == nil {
+		h.subscribers[channel] = make(map[*Subscriber]bool)
+	}
+	h.subscribers[channel][s] = true
+	s.channels[channel] = true
+}
+
+// SubscribeBook subscribes to an order book and sends its snapshot first.
+// Deltas received afterwards continue from the snapshot's sequence.
+func (h *Hub) SubscribeBook(s *Subscriber, book *orderbook.OrderBook) {
+	book.WithSnapshot(func(snapshot orderbook.BookSnapshot) {
+		channel := BookChannel(snapshot.MarketID, snapshot.Outcome)
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.subscribe(s, channel)
+		h.send(s, Message{Channel: channel, Type: TypeSnapshot, Data: snapshot})
+	})
+}
+
+// Unsubscribe removes a channel from a subscriber
+func (h *Hub) Unsubscribe(s *Subscriber, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers[channel], s)
+	if len(h.subscribers[channel]) == 0 {
+		delete(h.subscribers, channel)
+	}
+	delete(s.channels, channel)
+}
+
+// Remove unsubscribes a subscriber from everything and closes its channel
+func (h *Hub) Remove(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(s)
+}
+
+func (h *Hub) remove(s *Subscriber) {
+	if s.channels == nil {
+		return
+	}
+	for channel := range s.channels {
+		delete(h.subscribers[channel], s)
+		if len(h.subscribers[channel]) == 0 {
+			delete(h.subscribers, channel)
+		}
+	}
+	s.channels = nil
+	close(s.C)
+}
+
+// send delivers a message without blocking. A subscriber whose buffer is
+// full is removed; it can reconnect and resubscribe for a fresh snapshot.
+func (h *Hub) send(s *Subscriber, msg Message) {
+	select {
+	case s.C <- msg:
+	default:
+		h.remove(s)
+	}
+}
+
+// Publish sends a message to every subscriber of its channel
+func (h *Hub) Publish(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publish(msg)
+}
+
+func (h *Hub) publish(msg Message) {
+	for s := range h.subscribers[msg.Channel] {
+		h.send(s, msg)
+	}
+}
+
+// PublishBook forwards an order book event. It is the order book listener.
+func (h *Hub) PublishBook(event orderbook.BookEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.publish(Message{
+		Channel: BookChannel(event.MarketID, event.Outcome),
+		Type:    TypeDelta,
+		Data:    event,
+	})
+	h.recordTop(event)
+}
+
+// PublishTrade publishes a committed trade
+func (h *Hub) PublishTrade(trade models.Trade) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.publish(Message{Channel: TradesChannel(trade.MarketID), Type: TypeTrade, Data: trade})
+	h.recordTrade(trade)
+}
+
+// PublishStatus publishes a market's current status
+func (h *Hub) PublishStatus(market *models.Market) {
+	h.Publish(Message{
+		Channel: StatusChannel(market.ID),
+		Type:    TypeStatus,
+		Data: StatusUpdate{
+			MarketID:        market.ID,
+			Status:          market.Status,
+			ResolvedOutcome: market.ResolvedOutcome,
+		},
+	})
+}
+
+// PublishOrder publishes an order's current state to its owner
+func (h *Hub) PublishOrder(order *models.Order) {
+	h.Publish(Message{Channel: UserChannel(order.UserAddress), Type: TypeOrder, Data: order})
+}
+
+// PublishFill publishes a trade to its maker and taker. takerSide is the
+// side of the taker order.
+func (h *Hub) PublishFill(trade models.Trade, takerSide models.OrderSide) {
+	makerSide := models.OrderSideSell
+	if takerSide == models.OrderSideSell {
+		makerSide = models.OrderSideBuy
+	}
+
+	h.Publish(Message{
+		Channel: UserChannel(trade.TakerAddress),
+		Type:    TypeFill,
+		Data:    Fill{Trade: trade, OrderID: trade.TakerOrderID, Side: takerSide, Liquidity: "taker"},
+	})
+	h.Publish(Message{
+		Channel: UserChannel(trade.MakerAddress),
+		Type:    TypeFill,
+		Data:    Fill{Trade: trade, OrderID: trade.MakerOrderID, Side: makerSide, Liquidity: "maker"},
+	})
+}
+
+// PublishBalance publishes a user's balance after it changed
+func (h *Hub) PublishBalance(balance *models.UserBalance) {
+	h.Publish(Message{Channel: UserChannel(balance.UserAddress), Type: TypeBalance, Data: balance})
+}
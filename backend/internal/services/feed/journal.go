@@ -0,0 +1,129 @@
+package feed
+
+import (
+	"fmt"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/orderbook"
+)
+
+// journalSize is how many recent events of an outcome are kept for
+// clients resuming with Last-Event-ID
+const journalSize = 256
+
+// StreamChannel is the channel of an outcome's trade and top-of-book
+// events, as served over SSE
+func StreamChannel(marketID uint64, outcome uint8) string {
+	return fmt.Sprintf("stream:%d:%d", marketID, outcome)
+}
+
+// StreamEvent is a numbered event of an outcome stream. IDs increase by
+// one per event within an outcome.
+type StreamEvent struct {
+	ID   uint64      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// TopOfBook is the best bid and ask of an outcome
+type TopOfBook struct {
+	MarketID uint64           `json:"market_id"`
+	Outcome  uint8            `json:"outcome"`
+	BestBid  *orderbook.Level `json:"best_bid"`
+	BestAsk  *orderbook.Level `json:"best_ask"`
+}
+
+// journal is the ring of an outcome's recent stream events
+type journal struct {
+	events []StreamEvent // oldest first, at most journalSize
+	lastID uint64
+	top    *TopOfBook
+}
+
+type journalKey struct {
+	MarketID uint64
+	Outcome  uint8
+}
+
+// record appends an event to an outcome's journal and publishes it. The
+// caller holds h.mu.
+func (h *Hub) record(marketID uint64, outcome uint8, typ string, data interface{}) {
+	key := journalKey{MarketID: marketID, Outcome: outcome}
+	j := h.journals[key]
+	if j == nil {
+		j = &journal{}
+		h.journals[key] = j
+	}
+
+	j.lastID++
+	event := StreamEvent{ID: j.lastID, Type: typ, Data: data}
+	if len(j.events) == journalSize {
+		j.events = append(j.events[:0], j.events[1:]...)
+	}
+	j.events = append(j.events, event)
+
+	h.publish(Message{Channel: StreamChannel(marketID, outcome), Type: typ, Data: event})
+}
+
+// recordTop journals the top of book when it differs from the last one
+func (h *Hub) recordTop(event orderbook.BookEvent) {
+	top := &TopOfBook{
+		MarketID: event.MarketID,
+		Outcome:  event.Outcome,
+		BestBid:  event.BestBid,
+		BestAsk:  event.BestAsk,
+	}
+
+	if j := h.journals[journalKey{MarketID: event.MarketID, Outcome: event.Outcome}]; j != nil && j.top != nil &&
+		sameLevel(j.top.BestBid, top.BestBid) && sameLevel(j.top.BestAsk, top.BestAsk) {
+		return
+	}
+	h.record(event.MarketID, event.Outcome, TypeTop, top)
+	h.journals[journalKey{MarketID: event.MarketID, Outcome: event.Outcome}].top = top
+}
+
+func sameLevel(a, b *orderbook.Level) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Price.Equal(b.Price) && a.Quantity.Equal(b.Quantity)
+}
+
+// SubscribeStream subscribes to an outcome stream and returns the events
+// to replay first: those after lastID, or only the latest top of book
+// when lastID is zero. Live events on the subscriber follow the replay
+// without gaps or duplicates.
+func (h *Hub) SubscribeStream(s *Subscriber, marketID uint64, outcome uint8, lastID uint64) []StreamEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.subscribe(s, StreamChannel(marketID, outcome))
+
+	j := h.journals[journalKey{MarketID: marketID, Outcome: outcome}]
+	if j == nil {
+		return nil
+	}
+
+	// IDs restart with the process, so an ID from the future is stale
+	if lastID == 0 || lastID > j.lastID {
+		for i := len(j.events) - 1; i >= 0; i-- {
+			if j.events[i].Type == TypeTop {
+				return []StreamEvent{j.events[i]}
+			}
+		}
+		return nil
+	}
+
+	replay := make([]StreamEvent, 0)
+	for _, event := range j.events {
+		if event.ID > lastID {
+			replay = append(replay, event)
+		}
+	}
+	return replay
+}
+
+// recordTrade journals a trade on its outcome stream
+func (h *Hub) recordTrade(trade models.Trade) {
+	h.record(trade.MarketID, trade.Outcome, TypeTrade, trade)
+}
@@ -0,0 +1,96 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrKeyReused  = errors.New("idempotency key was used for a different request")
+)
+
+// Service stores the responses of requests made with an Idempotency-Key
+// for ttl, after which the key can be used again
+type Service struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+// NewService creates a new idempotency Service
+func NewService(db *gorm.DB, ttl time.Duration) *Service {
+	return &Service{db: db, ttl: ttl}
+}
+
+// Begin reserves key for a request with fingerprint. It returns the stored
+// record if the request has already completed, and nil if the caller now
+// holds the key and must Complete or Release it.
+func (s *Service) Begin(userAddress, key, fingerprint string, now time.Time) (*models.IdempotencyKey, error) {
+	if err := s.db.Where("user_address = ? AND key = ? AND created_at < ?", userAddress, key, now.Add(-s.ttl)).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, err
+	}
+
+	record := models.IdempotencyKey{
+		UserAddress: userAddress,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	if err := s.db.Where("user_address = ? AND key = ?", userAddress, key).First(&record).Error; err != nil {
+		return nil, err
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, ErrKeyReused
+	}
+	if record.StatusCode == 0 {
+		return nil, ErrInProgress
+	}
+	return &record, nil
+}
+
+// Complete stores the response to replay for key
+func (s *Service) Complete(userAddress, key string, statusCode int, response []byte) error {
+	return s.db.Model(&models.IdempotencyKey{}).
+		Where("user_address = ? AND key = ?", userAddress, key).
+		Updates(map[string]interface{}{"status_code": statusCode, "response": response}).Error
+}
+
+// Release frees key after a request that changed nothing, so that a retry
+// is handled afresh
+func (s *Service) Release(userAddress, key string) error {
+	return s.db.Where("user_address = ? AND key = ?", userAddress, key).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+// Run deletes expired keys every interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.db.Where("created_at < ?", now.Add(-s.ttl)).
+				Delete(&models.IdempotencyKey{}).Error; err != nil {
+				log.Printf("idempotency: prune failed: %v", err)
+			}
+		}
+	}
+}
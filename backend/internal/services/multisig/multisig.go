@@ -0,0 +1,159 @@
+package multisig
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prediction-market/backend/internal/models"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownSigner    = errors.New("unknown approval signer")
+	ErrInvalidSignature = errors.New("invalid approval signature")
+	ErrAlreadyApproved  = errors.New("signer already approved this action")
+)
+
+// Service collects M-of-N signed admin approvals per market action. Each
+// admin signs the message returned by Message with their ed25519 key.
+type Service struct {
+	db        *gorm.DB
+	signers   map[string]ed25519.PublicKey
+	threshold int
+}
+
+// Status reports how far an action is towards its threshold
+type Status struct {
+	Approvals int  `json:"approvals"`
+	Threshold int  `json:"threshold"`
+	Reached   bool `json:"reached"`
+}
+
+// NewService creates a new multisig Service
+func NewService(db *gorm.DB, signers map[string]ed25519.PublicKey, threshold int) *Service {
+	return &Service{db: db, signers: signers, threshold: threshold}
+}
+
+// ParseSigners parses "name:hexkey,name:hexkey" into ed25519 public keys
+func ParseSigners(s string) (map[string]ed25519.PublicKey, error) {
+	signers := make(map[string]ed25519.PublicKey)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, keyHex, ok := strings.Cut(entry, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid signer entry %q", entry)
+		}
+		key, err := hex.DecodeString(strings.TrimPrefix(keyHex, "0x"))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 key for signer %q", name)
+		}
+		signers[name] = ed25519.PublicKey(key)
+	}
+	return signers, nil
+}
+
+// Enabled reports whether approvals are required. Without configured
+// signers admin actions execute on a single admin request.
+func (s *Service) Enabled() bool {
+	return len(s.signers) > 0
+}
+
+// Threshold returns the number of approvals an action needs
+func (s *Service) Threshold() int {
+	return s.threshold
+}
+
+// PayloadHash returns the hex SHA-256 of an action payload
+func PayloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Message returns the bytes an admin signs to approve an action:
+// "<action>:<market id>:<hex sha256 of payload>"
+func Message(action models.AdminAction, marketID uint64, payload []byte) []byte {
+	return []byte(fmt.Sprintf("%s:%d:%s", action, marketID, PayloadHash(payload)))
+}
+
+// Approve verifies and records a signed approval and reports whether the
+// action has reached its threshold. Approvals with a different payload
+// count separately. A signer who already approved may submit again once
+// the threshold is reached, to retry an action that failed; before that
+// the repeat is ErrAlreadyApproved.
+func (s *Service) Approve(marketID uint64, action models.AdminAction, payload []byte, signer, signature string) (*Status, error) {
+	key, ok := s.signers[signer]
+	if !ok {
+		return nil, ErrUnknownSigner
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || !ed25519.Verify(key, Message(action, marketID, payload), sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	hash := PayloadHash(payload)
+	status := &Status{Threshold: s.threshold}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.AdminApproval{}).
+			Where("market_id = ? AND action = ? AND payload_hash = ? AND signer = ?", marketID, action, hash, signer).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing == 0 {
+			if err := tx.Create(&models.AdminApproval{
+				MarketID:    marketID,
+				Action:      action,
+				PayloadHash: hash,
+				Signer:      signer,
+				Payload:     datatypes.JSON(payload),
+				Signature:   signature,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var count int64
+		if err := tx.Model(&models.AdminApproval{}).
+			Where("market_id = ? AND action = ? AND payload_hash = ? AND executed = ?", marketID, action, hash, false).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		status.Approvals = int(count)
+		status.Reached = status.Approvals >= s.threshold
+		if existing > 0 && !status.Reached {
+			return ErrAlreadyApproved
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// MarkExecuted closes the approvals of an action. It is called inside the
+// action's transaction so that the approvals close if and only if the
+// action commits.
+func (s *Service) MarkExecuted(tx *gorm.DB, marketID uint64, action models.AdminAction, payload []byte) error {
+	return tx.Model(&models.AdminApproval{}).
+		Where("market_id = ? AND action = ? AND payload_hash = ? AND executed = ?", marketID, action, PayloadHash(payload), false).
+		Update("executed", true).Error
+}
+
+// Pending returns a market's approvals that have not been executed
+func (s *Service) Pending(marketID uint64) ([]models.AdminApproval, error) {
+	approvals := make([]models.AdminApproval, 0)
+	err := s.db.Where("market_id = ? AND executed = ?", marketID, false).
+		Order("created_at").Find(&approvals).Error
+	return approvals, err
+}
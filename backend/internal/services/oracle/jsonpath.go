@@ -0,0 +1,52 @@
+package oracle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lookup resolves a minimal JSONPath (dotted keys and [n] indexes, e.g.
+// $.data.prices[0].close) against a decoded JSON document
+func lookup(doc interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	node := doc
+
+	for path != "" {
+		var key string
+		if strings.HasPrefix(path, "[") {
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in path")
+			}
+			index, err := strconv.Atoi(path[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q in path", path[1:end])
+			}
+			items, ok := node.([]interface{})
+			if !ok || index < 0 || index >= len(items) {
+				return nil, fmt.Errorf("index %d not found", index)
+			}
+			node = items[index]
+			path = strings.TrimPrefix(path[end+1:], ".")
+			continue
+		}
+
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			key, path = path, ""
+		} else {
+			key, path = path[:end], strings.TrimPrefix(path[end:], ".")
+		}
+
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", key)
+		}
+		if node, ok = object[key]; !ok {
+			return nil, fmt.Errorf("key %q not found", key)
+		}
+	}
+
+	return node, nil
+}
@@ -0,0 +1,205 @@
+package oracle
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/resolution"
+	"github.com/shopspring/decimal"
+)
+
+// Source types a market can be bound to
+const (
+	TypeHTTPJSON   = "http_json"
+	TypeSignedFeed = "signed_feed"
+	TypeFile       = "file"
+	TypeStatic     = "static"
+)
+
+// Policy controls which sources markets may be bound to. The file and
+// static sources let whoever creates a market choose its outcome, so they
+// are only meant for local development.
+type Policy struct {
+	// DevSources allows the file and static sources
+	DevSources bool
+	// FileDir is the directory file sources read from. Their paths are
+	// relative to it and may not leave it.
+	FileDir string
+}
+
+// allows reports whether the policy permits a source type
+func (p Policy) allows(sourceType string) error {
+	switch sourceType {
+	case TypeFile:
+		if !p.DevSources || p.FileDir == "" {
+			return errors.New("file oracle is disabled")
+		}
+	case TypeStatic:
+		if !p.DevSources {
+			return errors.New("static oracle is disabled")
+		}
+	}
+	return nil
+}
+
+// Source fetches the observed value a market resolves on
+type Source interface {
+	Fetch(ctx context.Context) (decimal.Decimal, error)
+}
+
+// Rule maps an observed value to a categorical outcome by comparing it
+// against a threshold
+type Rule struct {
+	Operator       string          `json:"operator"` // gt, gte, lt, lte, eq
+	Threshold      decimal.Decimal `json:"threshold"`
+	OutcomeIfTrue  uint8           `json:"outcome_if_true"`
+	OutcomeIfFalse uint8           `json:"outcome_if_false"`
+}
+
+// Spec binds a market to an oracle source. It is stored as JSON on the market.
+type Spec struct {
+	Type string `json:"type"`
+	// URL for http_json and signed_feed, path relative to the policy's
+	// FileDir for file
+	URL string `json:"url,omitempty"`
+	// JSONPath to the value, e.g. $.data.price or $.results[0].value
+	Path string `json:"path,omitempty"`
+	// Hex-encoded ed25519 key that signs signed_feed payloads
+	PublicKey string `json:"public_key,omitempty"`
+	// Fixed value for static sources
+	Value *decimal.Decimal `json:"value,omitempty"`
+	// Comparison for categorical markets. Without a rule the value is the
+	// winning outcome number.
+	Rule *Rule `json:"rule,omitempty"`
+}
+
+// Validate checks that a spec is complete for a market of the given type
+// and number of outcomes and that the policy allows its source
+func (s *Spec) Validate(marketType models.MarketType, outcomes int, policy Policy) error {
+	if err := policy.allows(s.Type); err != nil {
+		return err
+	}
+
+	switch s.Type {
+	case TypeHTTPJSON, TypeFile:
+		if s.URL == "" || s.Path == "" {
+			return fmt.Errorf("%s oracle requires url and path", s.Type)
+		}
+		if s.Type == TypeFile && !filepath.IsLocal(s.URL) {
+			return errors.New("file oracle path must be relative and stay within the oracle directory")
+		}
+	case TypeSignedFeed:
+		if s.URL == "" || s.Path == "" {
+			return errors.New("signed_feed oracle requires url and path")
+		}
+		if key, err := hex.DecodeString(s.PublicKey); err != nil || len(key) != ed25519.PublicKeySize {
+			return errors.New("signed_feed oracle requires a hex ed25519 public_key")
+		}
+	case TypeStatic:
+		if s.Value == nil {
+			return errors.New("static oracle requires a value")
+		}
+	default:
+		return fmt.Errorf("unknown oracle type %q", s.Type)
+	}
+
+	if s.Rule == nil {
+		return nil
+	}
+	if marketType == models.MarketTypeScalar {
+		return errors.New("scalar markets resolve on the raw value and take no rule")
+	}
+	switch s.Rule.Operator {
+	case "gt", "gte", "lt", "lte", "eq":
+	default:
+		return fmt.Errorf("unknown rule operator %q", s.Rule.Operator)
+	}
+	for _, o := range []uint8{s.Rule.OutcomeIfTrue, s.Rule.OutcomeIfFalse} {
+		if o < 1 || int(o) > outcomes {
+			return errors.New("rule outcomes must be valid market outcomes")
+		}
+	}
+	return nil
+}
+
+// NewSource builds the Source described by a spec, refusing sources the
+// policy does not allow
+func NewSource(spec *Spec, client *http.Client, policy Policy) (Source, error) {
+	if err := policy.allows(spec.Type); err != nil {
+		return nil, err
+	}
+
+	switch spec.Type {
+	case TypeHTTPJSON:
+		return &HTTPJSONSource{URL: spec.URL, Path: spec.Path, Client: client}, nil
+	case TypeSignedFeed:
+		key, err := hex.DecodeString(spec.PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid signed feed public key")
+		}
+		return &SignedFeedSource{URL: spec.URL, Path: spec.Path, PublicKey: ed25519.PublicKey(key), Client: client}, nil
+	case TypeFile:
+		return &FileSource{Dir: policy.FileDir, Path: spec.URL, JSONPath: spec.Path}, nil
+	case TypeStatic:
+		if spec.Value == nil {
+			return nil, errors.New("static oracle requires a value")
+		}
+		return &StaticSource{Value: *spec.Value}, nil
+	}
+	return nil, fmt.Errorf("unknown oracle type %q", spec.Type)
+}
+
+// Proposal converts an observed value into a resolution proposal
+func (s *Spec) Proposal(marketType models.MarketType, value decimal.Decimal) (resolution.Proposal, error) {
+	if marketType == models.MarketTypeScalar {
+		return resolution.Proposal{Value: &value}, nil
+	}
+
+	if s.Rule == nil {
+		if !value.IsInteger() || value.LessThan(decimal.NewFromInt(1)) || value.GreaterThan(decimal.NewFromInt(255)) {
+			return resolution.Proposal{}, fmt.Errorf("oracle value %s is not an outcome number", value)
+		}
+		return resolution.Proposal{Outcome: uint8(value.IntPart())}, nil
+	}
+
+	if s.Rule.matches(value) {
+		return resolution.Proposal{Outcome: s.Rule.OutcomeIfTrue}, nil
+	}
+	return resolution.Proposal{Outcome: s.Rule.OutcomeIfFalse}, nil
+}
+
+func (r *Rule) matches(value decimal.Decimal) bool {
+	switch r.Operator {
+	case "gt":
+		return value.GreaterThan(r.Threshold)
+	case "gte":
+		return value.GreaterThanOrEqual(r.Threshold)
+	case "lt":
+		return value.LessThan(r.Threshold)
+	case "lte":
+		return value.LessThanOrEqual(r.Threshold)
+	case "eq":
+		return value.Equal(r.Threshold)
+	}
+	return false
+}
+
+// ParseSpec decodes a market's stored oracle spec
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// defaultClient is used when sources are built without an explicit client
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
@@ -0,0 +1,152 @@
+package oracle
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/shopspring/decimal"
+)
+
+// HTTPJSONSource reads a value from a JSON HTTP endpoint
+type HTTPJSONSource struct {
+	URL    string
+	Path   string
+	Client *http.Client
+}
+
+func (s *HTTPJSONSource) Fetch(ctx context.Context) (decimal.Decimal, error) {
+	body, err := get(ctx, s.Client, s.URL)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return extract(body, s.Path)
+}
+
+// SignedFeedSource reads a value from a feed that signs its payload with
+// ed25519. The response is {"payload": {...}, "signature": "<hex>"} and the
+// signature covers the raw payload bytes.
+type SignedFeedSource struct {
+	URL       string
+	Path      string
+	PublicKey ed25519.PublicKey
+	Client    *http.Client
+}
+
+func (s *SignedFeedSource) Fetch(ctx context.Context) (decimal.Decimal, error) {
+	body, err := get(ctx, s.Client, s.URL)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	var envelope struct {
+		Payload   json.RawMessage `json:"payload"`
+		Signature string          `json:"signature"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return decimal.Zero, fmt.Errorf("invalid signed feed response: %w", err)
+	}
+
+	signature, err := hex.DecodeString(envelope.Signature)
+	if err != nil {
+		return decimal.Zero, errors.New("invalid signed feed signature encoding")
+	}
+	if !ed25519.Verify(s.PublicKey, envelope.Payload, signature) {
+		return decimal.Zero, errors.New("signed feed signature verification failed")
+	}
+
+	return extract(envelope.Payload, s.Path)
+}
+
+// FileSource reads a value from a local JSON file at Path within Dir. It is
+// meant for tests and local development.
+type FileSource struct {
+	Dir      string
+	Path     string
+	JSONPath string
+}
+
+func (s *FileSource) Fetch(ctx context.Context) (decimal.Decimal, error) {
+	// OpenInRoot rejects paths, including symlinks, that escape Dir
+	f, err := os.OpenInRoot(s.Dir, s.Path)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	defer f.Close()
+
+	body, err := io.ReadAll(io.LimitReader(f, 1<<20))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return extract(body, s.JSONPath)
+}
+
+// StaticSource always returns the same value. It is meant for tests.
+type StaticSource struct {
+	Value decimal.Decimal
+}
+
+func (s *StaticSource) Fetch(ctx context.Context) (decimal.Decimal, error) {
+	return s.Value, nil
+}
+
+func get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	if client == nil {
+		client = defaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oracle endpoint returned %d", resp.StatusCode)
+	}
+
+	// Oracle responses are small; cap reads to guard against runaway bodies
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// extract decodes a JSON document and reads the number at path
+func extract(body []byte, path string) (decimal.Decimal, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return decimal.Zero, fmt.Errorf("invalid oracle json: %w", err)
+	}
+
+	node, err := lookup(doc, path)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	switch v := node.(type) {
+	case json.Number:
+		return decimal.NewFromString(v.String())
+	case string:
+		return decimal.NewFromString(v)
+	case bool:
+		// Booleans map to outcome numbers: true → 1 (e.g. "Yes"), false → 2
+		if v {
+			return decimal.NewFromInt(1), nil
+		}
+		return decimal.NewFromInt(2), nil
+	}
+	return decimal.Zero, fmt.Errorf("value at %s is not a number", path)
+}
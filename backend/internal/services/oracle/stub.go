@@ -0,0 +1,52 @@
+package oracle
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// StubFeed is an http.Handler that serves a settable JSON document, signed
+// when a private key is configured. It stands in for real oracle endpoints
+// in tests and local development (e.g. behind httptest.NewServer).
+type StubFeed struct {
+	mu         sync.RWMutex
+	doc        interface{}
+	privateKey ed25519.PrivateKey
+}
+
+// NewStubFeed creates a StubFeed serving doc. A non-nil key makes it serve
+// the signed_feed envelope instead of the bare document.
+func NewStubFeed(doc interface{}, key ed25519.PrivateKey) *StubFeed {
+	return &StubFeed{doc: doc, privateKey: key}
+}
+
+// Set replaces the served document
+func (f *StubFeed) Set(doc interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.doc = doc
+}
+
+func (f *StubFeed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.RLock()
+	payload, err := json.Marshal(f.doc)
+	f.mu.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if f.privateKey == nil {
+		w.Write(payload)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"payload":   json.RawMessage(payload),
+		"signature": hex.EncodeToString(ed25519.Sign(f.privateKey, payload)),
+	})
+}
@@ -0,0 +1,94 @@
+package oracle
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/resolution"
+	"gorm.io/gorm"
+)
+
+// Worker proposes resolutions for oracle-bound markets once their
+// resolution time has passed
+type Worker struct {
+	db         *gorm.DB
+	resolution *resolution.Service
+	client     *http.Client
+	policy     Policy
+}
+
+// NewWorker creates a new oracle Worker that only fetches from sources the
+// policy allows
+func NewWorker(db *gorm.DB, resolutionService *resolution.Service, timeout time.Duration, policy Policy) *Worker {
+	return &Worker{
+		db:         db,
+		resolution: resolutionService,
+		client:     &http.Client{Timeout: timeout},
+		policy:     policy,
+	}
+}
+
+// Run polls for due markets every interval until the context is cancelled.
+// Failed fetches are retried on the next tick.
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.ProposeDue(ctx, now)
+		}
+	}
+}
+
+// ProposeDue fetches the oracle value of every active market whose
+// resolution time has passed and proposes the resulting outcome
+func (w *Worker) ProposeDue(ctx context.Context, now time.Time) {
+	var markets []models.Market
+	if err := w.db.Where("status = ? AND oracle IS NOT NULL AND resolution_time <= ?", models.MarketStatusActive, now).
+		Find(&markets).Error; err != nil {
+		log.Printf("oracle: failed to load due markets: %v", err)
+		return
+	}
+
+	for i := range markets {
+		if err := w.propose(ctx, &markets[i]); err != nil {
+			log.Printf("oracle: market %d: %v", markets[i].ID, err)
+		}
+	}
+}
+
+func (w *Worker) propose(ctx context.Context, market *models.Market) error {
+	spec, err := ParseSpec(market.Oracle)
+	if err != nil {
+		return err
+	}
+
+	source, err := NewSource(spec, w.client, w.policy)
+	if err != nil {
+		return err
+	}
+
+	value, err := source.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	proposal, err := spec.Proposal(market.Type, value)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.resolution.Propose(market.ID, proposal, nil); err != nil {
+		return err
+	}
+
+	log.Printf("oracle: proposed resolution for market %d from value %s", market.ID, value)
+	return nil
+}
@@ -0,0 +1,140 @@
+package orderbook
+
+import (
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// LevelChange is the new total quantity of a price level. A zero quantity
+// means the level was removed.
+type LevelChange struct {
+	Side     models.OrderSide `json:"side"`
+	Price    decimal.Decimal  `json:"price"`
+	Quantity decimal.Decimal  `json:"quantity"`
+}
+
+// BookEvent describes the level changes of one order book operation and
+// the resulting top of book. Sequence increases by one per event, so a gap
+// means events were missed.
+type BookEvent struct {
+	MarketID uint64        `json:"market_id"`
+	Outcome  uint8         `json:"outcome"`
+	Sequence uint64        `json:"sequence"`
+	Changes  []LevelChange `json:"changes"`
+	BestBid  *Level        `json:"best_bid"`
+	BestAsk  *Level        `json:"best_ask"`
+}
+
+// Level is an aggregated price level of a snapshot
+type Level struct {
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+// BookSnapshot is the full aggregated book as of Sequence
+type BookSnapshot struct {
+	MarketID uint64  `json:"market_id"`
+	Outcome  uint8   `json:"outcome"`
+	Sequence uint64  `json:"sequence"`
+	Bids     []Level `json:"bids"`
+	Asks     []Level `json:"asks"`
+}
+
+// Listener receives book events. It is called with the book locked, so it
+// must not block or call back into the book.
+type Listener func(BookEvent)
+
+// SetListener registers the listener that receives the events of every
+// order book
+func (m *OrderBookManager) SetListener(l Listener) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.listener = l
+	for _, book := range m.books {
+		book.mu.Lock()
+		book.listener = l
+		book.mu.Unlock()
+	}
+}
+
+// WithSnapshot calls fn with a snapshot of the book. No events are
+// published while fn runs, so a subscriber registered inside fn receives
+// exactly the events that follow the snapshot.
+func (ob *OrderBook) WithSnapshot(fn func(BookSnapshot)) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	snapshot := BookSnapshot{
+		MarketID: ob.MarketID,
+		Outcome:  ob.Outcome,
+		Sequence: ob.sequence,
+		Bids:     make([]Level, len(ob.Buys)),
+		Asks:     make([]Level, len(ob.Sells)),
+	}
+	for i, level := range ob.Buys {
+		snapshot.Bids[i] = Level{Price: level.Price, Quantity: level.Quantity}
+	}
+	for i, level := range ob.Sells {
+		snapshot.Asks[i] = Level{Price: level.Price, Quantity: level.Quantity}
+	}
+	fn(snapshot)
+}
+
+// touch marks a price level as changed by the current operation
+func (ob *OrderBook) touch(side models.OrderSide, price decimal.Decimal) {
+	for _, c := range ob.pending {
+		if c.Side == side && c.Price.Equal(price) {
+			return
+		}
+	}
+	ob.pending = append(ob.pending, LevelChange{Side: side, Price: price})
+}
+
+// publish emits the levels touched by the current operation. The caller
+// holds the write lock.
+func (ob *OrderBook) publish() {
+	if len(ob.pending) == 0 {
+		return
+	}
+
+	changes := make([]LevelChange, len(ob.pending))
+	for i, c := range ob.pending {
+		c.Quantity = ob.levelQuantity(c.Side, c.Price)
+		changes[i] = c
+	}
+	ob.pending = ob.pending[:0]
+	ob.sequence++
+
+	if ob.listener == nil {
+		return
+	}
+	event := BookEvent{
+		MarketID: ob.MarketID,
+		Outcome:  ob.Outcome,
+		Sequence: ob.sequence,
+		Changes:  changes,
+	}
+	if len(ob.Buys) > 0 {
+		event.BestBid = &Level{Price: ob.Buys[0].Price, Quantity: ob.Buys[0].Quantity}
+	}
+	if len(ob.Sells) > 0 {
+		event.BestAsk = &Level{Price: ob.Sells[0].Price, Quantity: ob.Sells[0].Quantity}
+	}
+	ob.listener(event)
+}
+
+// levelQuantity returns the resting quantity at a price, zero if the level
+// does not exist
+func (ob *OrderBook) levelQuantity(side models.OrderSide, price decimal.Decimal) decimal.Decimal {
+	levels := ob.Sells
+	if side == models.OrderSideBuy {
+		levels = ob.Buys
+	}
+	for _, level := range levels {
+		if level.Price.Equal(price) {
+			return level.Quantity
+		}
+	}
+	return decimal.Zero
+}
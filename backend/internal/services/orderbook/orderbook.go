@@ -24,12 +24,17 @@ type OrderBook struct {
 	Buys     []PriceLevel // sorted by price descending (best buy first)
 	Sells    []PriceLevel // sorted by price ascending (best sell first)
 	mu       sync.RWMutex
+
+	sequence uint64
+	listener Listener
+	pending  []LevelChange
 }
 
 // OrderBookManager manages multiple order books
 type OrderBookManager struct {
-	books map[string]*OrderBook // key: "marketId-outcome"
-	mu    sync.RWMutex
+	books    map[string]*OrderBook // key: "marketId-outcome"
+	listener Listener
+	mu       sync.RWMutex
 }
 
 // MatchResult represents the result of order matching
@@ -37,6 +42,10 @@ type MatchResult struct {
 	Trades      []models.Trade
 	MakerOrders []*models.Order
 	TakerOrder  *models.Order
+	// Expired are resting orders past their signed expiry that matching
+	// removed from the book instead of filling. They are marked cancelled;
+	// the caller releases their funds.
+	Expired []*models.Order
 }
 
 // NewOrderBookManager creates a new OrderBookManager
@@ -76,11 +85,32 @@ func (m *OrderBookManager) GetOrCreate(marketID uint64, outcome uint8) *OrderBoo
 		Outcome:  outcome,
 		Buys:     make([]PriceLevel, 0),
 		Sells:    make([]PriceLevel, 0),
+		listener: m.listener,
 	}
 	m.books[key] = book
 	return book
 }
 
+// Get returns the order book of a market outcome if one exists
+func (m *OrderBookManager) Get(marketID uint64, outcome uint8) (*OrderBook, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	book, exists := m.books[makeKey(marketID, outcome)]
+	return book, exists
+}
+
+// RemoveMarket drops every order book of a market
+func (m *OrderBookManager) RemoveMarket(marketID uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, book := range m.books {
+		if book.MarketID == marketID {
+			delete(m.books, key)
+		}
+	}
+}
+
 // GetDepth returns a copy of the order book for a specific market outcome
 func (m *OrderBookManager) GetDepth(marketID uint64, outcome uint8) *OrderBook {
 	key := makeKey(marketID, outcome)
@@ -131,6 +161,28 @@ func (m *OrderBookManager) GetDepth(marketID uint64, outcome uint8) *OrderBook {
 	return copyBook
 }
 
+// BestBid returns the highest resting buy price, if any
+func (ob *OrderBook) BestBid() (decimal.Decimal, bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	if len(ob.Buys) == 0 {
+		return decimal.Zero, false
+	}
+	return ob.Buys[0].Price, true
+}
+
+// BestAsk returns the lowest resting sell price, if any
+func (ob *OrderBook) BestAsk() (decimal.Decimal, bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	if len(ob.Sells) == 0 {
+		return decimal.Zero, false
+	}
+	return ob.Sells[0].Price, true
+}
+
 // AddOrder adds an order to the order book and performs matching
 func (ob *OrderBook) AddOrder(order *models.Order) (*MatchResult, error) {
 	if order == nil {
@@ -162,7 +214,9 @@ func (ob *OrderBook) AddOrder(order *models.Order) (*MatchResult, error) {
 	// Add remaining quantity to the book if not fully filled
 	if order.RemainingQuantity().GreaterThan(decimal.Zero) {
 		ob.addToBook(order)
+		ob.touch(order.Side, order.Price)
 	}
+	ob.publish()
 
 	return result, nil
 }
@@ -170,6 +224,7 @@ func (ob *OrderBook) AddOrder(order *models.Order) (*MatchResult, error) {
 // matchBuyOrder matches a buy order against sell levels
 func (ob *OrderBook) matchBuyOrder(order *models.Order, result *MatchResult) {
 	remaining := order.RemainingQuantity()
+	now := time.Now()
 
 	for len(ob.Sells) > 0 && remaining.GreaterThan(decimal.Zero) {
 		level := &ob.Sells[0]
@@ -181,6 +236,15 @@ func (ob *OrderBook) matchBuyOrder(order *models.Order, result *MatchResult) {
 
 		for len(level.Orders) > 0 && remaining.GreaterThan(decimal.Zero) {
 			makerOrder := level.Orders[0]
+			if makerOrder.Expired(now) {
+				// The maker's signature no longer authorises a fill
+				level.Quantity = level.Quantity.Sub(makerOrder.RemainingQuantity())
+				level.Orders = level.Orders[1:]
+				makerOrder.Status = models.OrderStatusCancelled
+				result.Expired = append(result.Expired, makerOrder)
+				ob.touch(makerOrder.Side, level.Price)
+				continue
+			}
 			makerRemaining := makerOrder.RemainingQuantity()
 
 			// Determine the trade quantity
@@ -216,6 +280,7 @@ func (ob *OrderBook) matchBuyOrder(order *models.Order, result *MatchResult) {
 
 			// Update level quantity
 			level.Quantity = level.Quantity.Sub(tradeQty)
+			ob.touch(makerOrder.Side, level.Price)
 
 			// Remove fully filled maker order from level
 			if makerOrder.RemainingQuantity().IsZero() {
@@ -233,6 +298,7 @@ func (ob *OrderBook) matchBuyOrder(order *models.Order, result *MatchResult) {
 // matchSellOrder matches a sell order against buy levels
 func (ob *OrderBook) matchSellOrder(order *models.Order, result *MatchResult) {
 	remaining := order.RemainingQuantity()
+	now := time.Now()
 
 	for len(ob.Buys) > 0 && remaining.GreaterThan(decimal.Zero) {
 		level := &ob.Buys[0]
@@ -244,6 +310,15 @@ func (ob *OrderBook) matchSellOrder(order *models.Order, result *MatchResult) {
 
 		for len(level.Orders) > 0 && remaining.GreaterThan(decimal.Zero) {
 			makerOrder := level.Orders[0]
+			if makerOrder.Expired(now) {
+				// The maker's signature no longer authorises a fill
+				level.Quantity = level.Quantity.Sub(makerOrder.RemainingQuantity())
+				level.Orders = level.Orders[1:]
+				makerOrder.Status = models.OrderStatusCancelled
+				result.Expired = append(result.Expired, makerOrder)
+				ob.touch(makerOrder.Side, level.Price)
+				continue
+			}
 			makerRemaining := makerOrder.RemainingQuantity()
 
 			// Determine the trade quantity
@@ -279,6 +354,7 @@ func (ob *OrderBook) matchSellOrder(order *models.Order, result *MatchResult) {
 
 			// Update level quantity
 			level.Quantity = level.Quantity.Sub(tradeQty)
+			ob.touch(makerOrder.Side, level.Price)
 
 			// Remove fully filled maker order from level
 			if makerOrder.RemainingQuantity().IsZero() {
@@ -376,10 +452,17 @@ func (ob *OrderBook) RemoveOrder(order *models.Order) bool {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
 
+	removed := false
 	if order.Side == models.OrderSideBuy {
-		return ob.removeFromBuys(order)
+		removed = ob.removeFromBuys(order)
+	} else {
+		removed = ob.removeFromSells(order)
+	}
+	if removed {
+		ob.touch(order.Side, order.Price)
+		ob.publish()
 	}
-	return ob.removeFromSells(order)
+	return removed
 }
 
 // removeFromBuys removes an order from the Buys side
@@ -0,0 +1,481 @@
+package resolution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/orderbook"
+	"github.com/prediction-market/backend/internal/services/settlement"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMarketNotFound    = errors.New("market not found")
+	ErrInvalidState      = errors.New("market cannot be resolved in its current state")
+	ErrInvalidResolution = errors.New("invalid resolution")
+	ErrWindowClosed      = errors.New("dispute window has closed")
+	ErrWindowOpen        = errors.New("dispute window is still open")
+	ErrAlreadyDisputed   = errors.New("resolution already disputed by this user")
+	ErrInsufficientBond  = errors.New("insufficient balance for dispute bond")
+)
+
+// Proposal is a candidate resolution. Payouts takes precedence, then Value
+// for scalar markets, then Outcome for categorical markets.
+type Proposal struct {
+	Outcome uint8             `json:"outcome"`
+	Value   *decimal.Decimal  `json:"value"`
+	Payouts []decimal.Decimal `json:"payouts"`
+}
+
+// Service runs two-step resolution: a proposal opens a challenge window in
+// which users can post bonded disputes, and payouts happen only once the
+// resolution is finalised
+type Service struct {
+	db       *gorm.DB
+	obm      *orderbook.OrderBookManager
+	window   time.Duration
+	bond     decimal.Decimal
+	treasury string
+	listener Listener
+}
+
+// Listener is notified of committed changes: market status transitions and
+// the orders and balances they touched
+type Listener interface {
+	PublishStatus(market *models.Market)
+	PublishOrder(order *models.Order)
+	PublishBalance(balance *models.UserBalance)
+}
+
+// NewService creates a new resolution Service. Forfeited dispute bonds are
+// credited to the treasury account.
+func NewService(db *gorm.DB, obm *orderbook.OrderBookManager, window time.Duration, bond decimal.Decimal, treasury string) *Service {
+	return &Service{db: db, obm: obm, window: window, bond: bond, treasury: treasury}
+}
+
+// SetListener registers the listener notified of committed changes
+func (s *Service) SetListener(l Listener) {
+	s.listener = l
+}
+
+func (s *Service) notify(market *models.Market, orders []models.Order, balances []models.UserBalance) {
+	if s.listener == nil {
+		return
+	}
+	for i := range orders {
+		s.listener.PublishOrder(&orders[i])
+	}
+	for i := range balances {
+		s.listener.PublishBalance(&balances[i])
+	}
+	s.listener.PublishStatus(market)
+}
+
+// Bond returns the amount locked to open a dispute
+func (s *Service) Bond() decimal.Decimal {
+	return s.bond
+}
+
+func loadMarket(tx *gorm.DB, marketID uint64) (*models.Market, error) {
+	var market models.Market
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&market, marketID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrMarketNotFound
+		}
+		return nil, err
+	}
+	return &market, nil
+}
+
+// apply computes the payout vector for a proposal and records it on the market
+func apply(market *models.Market, p Proposal) error {
+	var outcomes []string
+	if err := json.Unmarshal(market.Outcomes, &outcomes); err != nil {
+		return errors.New("corrupted market data")
+	}
+
+	// Per-share payout of each outcome
+	payouts := make([]decimal.Decimal, len(outcomes))
+	market.ResolvedOutcome = nil
+	market.ResolvedValue = nil
+
+	switch {
+	case p.Payouts != nil:
+		if err := settlement.ValidatePayouts(p.Payouts, len(outcomes)); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidResolution, err)
+		}
+		payouts = p.Payouts
+		// Record a single winner when the vector pays one outcome in full
+		for i, v := range payouts {
+			if v.Equal(decimal.NewFromInt(1)) {
+				winner := uint8(i + 1)
+				market.ResolvedOutcome = &winner
+			}
+		}
+	case market.Type == models.MarketTypeScalar:
+		if p.Value == nil {
+			return fmt.Errorf("%w: scalar markets are resolved with a value or payouts", ErrInvalidResolution)
+		}
+		long, short := market.ScalarPayouts(*p.Value)
+		payouts[models.ScalarOutcomeLong-1] = long
+		payouts[models.ScalarOutcomeShort-1] = short
+		value := *p.Value
+		market.ResolvedValue = &value
+	default:
+		if int(p.Outcome) < 1 || int(p.Outcome) > len(outcomes) {
+			return fmt.Errorf("%w: invalid outcome", ErrInvalidResolution)
+		}
+		payouts[p.Outcome-1] = decimal.NewFromInt(1)
+		outcome := p.Outcome
+		market.ResolvedOutcome = &outcome
+	}
+
+	payoutsJSON, err := json.Marshal(payouts)
+	if err != nil {
+		return err
+	}
+	market.Payouts = datatypes.JSON(payoutsJSON)
+	return nil
+}
+
+// Approvals closes the admin approvals that authorised an action. It runs
+// inside the action's transaction so that they close only if the action
+// commits. Actions that needed no approval pass nil.
+type Approvals func(tx *gorm.DB) error
+
+func (a Approvals) close(tx *gorm.DB) error {
+	if a == nil {
+		return nil
+	}
+	return a(tx)
+}
+
+// Propose records a proposed resolution on an active market, halts trading
+// and opens the dispute window
+func (s *Service) Propose(marketID uint64, p Proposal, approvals Approvals) (*models.Market, error) {
+	var market *models.Market
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if market, err = loadMarket(tx, marketID); err != nil {
+			return err
+		}
+		if market.Status != models.MarketStatusActive {
+			return ErrInvalidState
+		}
+		if err := apply(market, p); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		deadline := now.Add(s.window)
+		market.ProposedAt = &now
+		market.DisputeDeadline = &deadline
+		market.Status = models.MarketStatusProposed
+		if err := tx.Save(market).Error; err != nil {
+			return err
+		}
+		return approvals.close(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify(market, nil, nil)
+	return market, nil
+}
+
+// Dispute locks the dispute bond from a user's balance and challenges the
+// proposed resolution. Payouts optionally suggests the correct vector.
+func (s *Service) Dispute(marketID uint64, user, reason string, payouts []decimal.Decimal) (*models.Dispute, error) {
+	var dispute *models.Dispute
+	var market *models.Market
+	var balance models.UserBalance
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if market, err = loadMarket(tx, marketID); err != nil {
+			return err
+		}
+		if market.Status != models.MarketStatusProposed && market.Status != models.MarketStatusDisputed {
+			return ErrInvalidState
+		}
+		if market.DisputeDeadline == nil || time.Now().After(*market.DisputeDeadline) {
+			return ErrWindowClosed
+		}
+
+		var suggested datatypes.JSON
+		if payouts != nil {
+			var outcomes []string
+			if err := json.Unmarshal(market.Outcomes, &outcomes); err != nil {
+				return errors.New("corrupted market data")
+			}
+			if err := settlement.ValidatePayouts(payouts, len(outcomes)); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidResolution, err)
+			}
+			if suggested, err = json.Marshal(payouts); err != nil {
+				return err
+			}
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Dispute{}).
+			Where("market_id = ? AND user_address = ?", marketID, user).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyDisputed
+		}
+
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").
+			FirstOrCreate(&balance, models.UserBalance{UserAddress: user}).Error; err != nil {
+			return err
+		}
+		if balance.Available.LessThan(s.bond) {
+			return ErrInsufficientBond
+		}
+		balance.Available = balance.Available.Sub(s.bond)
+		balance.Locked = balance.Locked.Add(s.bond)
+		if err := tx.Save(&balance).Error; err != nil {
+			return err
+		}
+
+		dispute = &models.Dispute{
+			MarketID:    marketID,
+			UserAddress: user,
+			Bond:        s.bond,
+			Reason:      reason,
+			Payouts:     suggested,
+			Status:      models.DisputeStatusOpen,
+		}
+		if err := tx.Create(dispute).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.BalanceLog{
+			UserAddress:  user,
+			ChangeType:   "dispute_bond",
+			Amount:       s.bond.Neg(),
+			BalanceAfter: balance.Available,
+			ReferenceID:  &dispute.ID,
+		}).Error; err != nil {
+			return err
+		}
+
+		market.Status = models.MarketStatusDisputed
+		return tx.Save(market).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify(market, nil, []models.UserBalance{balance})
+	return dispute, nil
+}
+
+// Finalize confirms the proposed resolution, or overturns it when override
+// is non-nil, and pays out. Undisputed proposals can only be confirmed once
+// the dispute window has passed. Bonds of disputes are returned when the
+// proposal is overturned and forfeited when it is confirmed.
+func (s *Service) Finalize(marketID uint64, override *Proposal, approvals Approvals) (*models.Market, error) {
+	var market *models.Market
+	var result *settlement.Result
+	var bonds []models.UserBalance
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if market, err = loadMarket(tx, marketID); err != nil {
+			return err
+		}
+		if market.Status != models.MarketStatusProposed && market.Status != models.MarketStatusDisputed {
+			return ErrInvalidState
+		}
+		if override == nil && market.Status == models.MarketStatusProposed &&
+			market.DisputeDeadline != nil && time.Now().Before(*market.DisputeDeadline) {
+			return ErrWindowOpen
+		}
+
+		if override != nil {
+			if err := apply(market, *override); err != nil {
+				return err
+			}
+		}
+
+		if bonds, err = s.closeDisputes(tx, marketID, override != nil); err != nil {
+			return err
+		}
+
+		var payouts []decimal.Decimal
+		if err := json.Unmarshal(market.Payouts, &payouts); err != nil {
+			return errors.New("corrupted market data")
+		}
+
+		market.Status = models.MarketStatusResolved
+		if err := tx.Save(market).Error; err != nil {
+			return err
+		}
+		if result, err = settlement.SettleMarket(tx, market.ID, payouts); err != nil {
+			return err
+		}
+		return approvals.close(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Resting orders were cancelled by settlement
+	s.obm.RemoveMarket(market.ID)
+	s.notify(market, result.Orders, append(bonds, result.Balances...))
+	return market, nil
+}
+
+// Cancel voids a market that has not been finalised. Open disputes get
+// their bonds back and all locked funds are released without payouts.
+func (s *Service) Cancel(marketID uint64, approvals Approvals) (*models.Market, error) {
+	var market *models.Market
+	var result *settlement.Result
+	var bonds []models.UserBalance
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if market, err = loadMarket(tx, marketID); err != nil {
+			return err
+		}
+		switch market.Status {
+		case models.MarketStatusPending, models.MarketStatusActive,
+			models.MarketStatusProposed, models.MarketStatusDisputed:
+		default:
+			return ErrInvalidState
+		}
+
+		if bonds, err = s.closeDisputes(tx, marketID, true); err != nil {
+			return err
+		}
+
+		market.Status = models.MarketStatusCancelled
+		if err := tx.Save(market).Error; err != nil {
+			return err
+		}
+		if result, err = settlement.CancelMarket(tx, market.ID); err != nil {
+			return err
+		}
+		return approvals.close(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.obm.RemoveMarket(market.ID)
+	s.notify(market, result.Orders, append(bonds, result.Balances...))
+	return market, nil
+}
+
+// closeDisputes settles the bonds of a market's open disputes: upheld bonds
+// are refunded and rejected ones are forfeited to the treasury. It returns
+// the updated balances.
+func (s *Service) closeDisputes(tx *gorm.DB, marketID uint64, upheld bool) ([]models.UserBalance, error) {
+	var disputes []models.Dispute
+	if err := tx.Where("market_id = ? AND status = ?", marketID, models.DisputeStatusOpen).
+		Order("user_address").Find(&disputes).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	balances := make([]models.UserBalance, 0, len(disputes)+1)
+	forfeited := decimal.Zero
+	for i := range disputes {
+		d := &disputes[i]
+
+		var balance models.UserBalance
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").
+			First(&balance, "user_address = ?", d.UserAddress).Error; err != nil {
+			return nil, err
+		}
+
+		changeType := "dispute_forfeit"
+		amount := decimal.Zero
+		balance.Locked = balance.Locked.Sub(d.Bond)
+		d.Status = models.DisputeStatusRejected
+		if upheld {
+			changeType = "dispute_refund"
+			amount = d.Bond
+			balance.Available = balance.Available.Add(d.Bond)
+			d.Status = models.DisputeStatusUpheld
+		} else {
+			forfeited = forfeited.Add(d.Bond)
+		}
+		if err := tx.Save(&balance).Error; err != nil {
+			return nil, err
+		}
+
+		if err := tx.Create(&models.BalanceLog{
+			UserAddress:  d.UserAddress,
+			ChangeType:   changeType,
+			Amount:       amount,
+			BalanceAfter: balance.Available,
+			ReferenceID:  &d.ID,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		d.ResolvedAt = &now
+		if err := tx.Save(d).Error; err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+
+	if !forfeited.IsPositive() {
+		return balances, nil
+	}
+
+	var treasury models.UserBalance
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").
+		FirstOrCreate(&treasury, models.UserBalance{UserAddress: s.treasury}).Error; err != nil {
+		return nil, err
+	}
+	treasury.Available = treasury.Available.Add(forfeited)
+	if err := tx.Save(&treasury).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(&models.BalanceLog{
+		UserAddress:  s.treasury,
+		ChangeType:   "dispute_forfeit",
+		Amount:       forfeited,
+		BalanceAfter: treasury.Available,
+		ReferenceID:  &marketID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return append(balances, treasury), nil
+}
+
+// Run finalises undisputed proposals whose dispute window has passed until
+// the context is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			var ids []uint64
+			if err := s.db.Model(&models.Market{}).
+				Where("status = ? AND dispute_deadline <= ?", models.MarketStatusProposed, now).
+				Pluck("id", &ids).Error; err != nil {
+				log.Printf("resolution: failed to load expired proposals: %v", err)
+				continue
+			}
+			for _, id := range ids {
+				if _, err := s.Finalize(id, nil, nil); err != nil {
+					log.Printf("resolution: failed to finalise market %d: %v", id, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package rewards
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/orderbook"
+	"github.com/prediction-market/backend/internal/services/settlement"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Service samples resting liquidity and pays out per-market reward pools
+type Service struct {
+	db             *gorm.DB
+	obm            *orderbook.OrderBookManager
+	sampleInterval time.Duration
+	epochDuration  time.Duration
+	maxSpread      decimal.Decimal
+	treasury       string
+	listener       Listener
+}
+
+// Listener is notified of balances credited by a committed distribution
+type Listener interface {
+	PublishBalance(balance *models.UserBalance)
+}
+
+// NewService creates a new rewards Service that pays rewards out of the
+// treasury account
+func NewService(db *gorm.DB, obm *orderbook.OrderBookManager, sampleInterval, epochDuration time.Duration, maxSpread decimal.Decimal, treasury string) *Service {
+	return &Service{
+		db:             db,
+		obm:            obm,
+		sampleInterval: sampleInterval,
+		epochDuration:  epochDuration,
+		maxSpread:      maxSpread,
+		treasury:       treasury,
+	}
+}
+
+// SetListener registers the listener notified of reward payouts
+func (s *Service) SetListener(l Listener) {
+	s.listener = l
+}
+
+// EpochStart returns the start of the epoch containing t
+func (s *Service) EpochStart(t time.Time) time.Time {
+	return t.UTC().Truncate(s.epochDuration)
+}
+
+// Run samples the order books every sample interval and distributes
+// finished epochs until the context is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.Sample(now); err != nil {
+				log.Printf("rewards: sample failed: %v", err)
+			}
+			if err := s.DistributeFinished(now); err != nil {
+				log.Printf("rewards: distribution failed: %v", err)
+			}
+		}
+	}
+}
+
+// Sample scores every maker quoting within the max spread of the midpoint
+// in markets that have a reward pool. Score is size × seconds resting.
+func (s *Service) Sample(now time.Time) error {
+	var markets []models.Market
+	if err := s.db.
+		Joins("JOIN reward_pools ON reward_pools.market_id = markets.id").
+		Where("markets.status = ? AND reward_pools.amount_per_epoch > 0", models.MarketStatusActive).
+		Find(&markets).Error; err != nil {
+		return err
+	}
+
+	epoch := s.EpochStart(now)
+	weight := decimal.NewFromFloat(s.sampleInterval.Seconds())
+
+	for _, market := range markets {
+		var outcomes []string
+		if err := json.Unmarshal(market.Outcomes, &outcomes); err != nil {
+			log.Printf("rewards: market %d has corrupted outcomes: %v", market.ID, err)
+			continue
+		}
+
+		scores := make(map[string]decimal.Decimal)
+		for i := range outcomes {
+			book := s.obm.GetDepth(market.ID, uint8(i+1))
+			if book == nil {
+				continue
+			}
+			s.scoreBook(book, weight, scores)
+		}
+
+		for user, score := range scores {
+			reward := models.MakerReward{
+				EpochStart:  epoch,
+				MarketID:    market.ID,
+				UserAddress: user,
+				Score:       score,
+			}
+			if err := s.db.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "epoch_start"}, {Name: "market_id"}, {Name: "user_address"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"score":      gorm.Expr("maker_rewards.score + EXCLUDED.score"),
+					"updated_at": now,
+				}),
+			}).Create(&reward).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// scoreBook adds the score of every order near the midpoint of a two-sided book
+func (s *Service) scoreBook(book *orderbook.OrderBook, weight decimal.Decimal, scores map[string]decimal.Decimal) {
+	// One-sided books have no meaningful midpoint
+	if len(book.Buys) == 0 || len(book.Sells) == 0 {
+		return
+	}
+
+	mid := book.Buys[0].Price.Add(book.Sells[0].Price).Div(decimal.NewFromInt(2))
+
+	score := func(levels []orderbook.PriceLevel) {
+		for _, level := range levels {
+			if level.Price.Sub(mid).Abs().GreaterThan(s.maxSpread) {
+				// Levels are sorted away from the midpoint
+				break
+			}
+			for _, order := range level.Orders {
+				size := order.RemainingQuantity().Mul(weight)
+				scores[order.UserAddress] = scores[order.UserAddress].Add(size)
+			}
+		}
+	}
+
+	score(book.Buys)
+	score(book.Sells)
+}
+
+// DistributeFinished pays out every epoch that ended before now and has not been paid
+func (s *Service) DistributeFinished(now time.Time) error {
+	current := s.EpochStart(now)
+
+	type pending struct {
+		EpochStart time.Time
+		MarketID   uint64
+	}
+	var epochs []pending
+	if err := s.db.Model(&models.MakerReward{}).
+		Select("DISTINCT epoch_start, market_id").
+		Where("paid = ? AND epoch_start < ?", false, current).
+		Scan(&epochs).Error; err != nil {
+		return err
+	}
+
+	for _, e := range epochs {
+		if err := s.distribute(e.EpochStart, e.MarketID, now); err != nil {
+			// Left unpaid until the treasury is topped up; other markets
+			// still get paid
+			if errors.Is(err, settlement.ErrInsufficientFunds) {
+				log.Printf("rewards: market %d epoch %s: %v", e.MarketID, e.EpochStart.Format(time.RFC3339), err)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// distribute splits a market's pool across makers pro rata to score and
+// debits the total from the treasury. Nothing is paid if the treasury
+// cannot cover it.
+func (s *Service) distribute(epoch time.Time, marketID uint64, now time.Time) error {
+	var credited []models.UserBalance
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var pool models.RewardPool
+		if err := tx.First(&pool, "market_id = ?", marketID).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		var rewards []models.MakerReward
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").
+			Where("epoch_start = ? AND market_id = ? AND paid = ?", epoch, marketID, false).
+			Find(&rewards).Error; err != nil {
+			return err
+		}
+
+		total := decimal.Zero
+		for _, r := range rewards {
+			total = total.Add(r.Score)
+		}
+		paid := decimal.Zero
+
+		for i := range rewards {
+			reward := &rewards[i]
+			amount := decimal.Zero
+			if total.GreaterThan(decimal.Zero) {
+				amount = pool.AmountPerEpoch.Mul(reward.Score).Div(total).Truncate(6)
+			}
+
+			if amount.GreaterThan(decimal.Zero) {
+				var balance models.UserBalance
+				if err := tx.Set("gorm:query_option", "FOR UPDATE").
+					FirstOrCreate(&balance, models.UserBalance{UserAddress: reward.UserAddress}).Error; err != nil {
+					return err
+				}
+
+				balance.Available = balance.Available.Add(amount)
+				if err := tx.Save(&balance).Error; err != nil {
+					return err
+				}
+
+				if err := tx.Create(&models.BalanceLog{
+					UserAddress:  reward.UserAddress,
+					ChangeType:   "reward",
+					Amount:       amount,
+					BalanceAfter: balance.Available,
+					ReferenceID:  &reward.ID,
+				}).Error; err != nil {
+					return err
+				}
+				credited = append(credited, balance)
+				paid = paid.Add(amount)
+			}
+
+			paidAt := now
+			reward.Amount = amount
+			reward.Paid = true
+			reward.PaidAt = &paidAt
+			if err := tx.Save(reward).Error; err != nil {
+				return err
+			}
+		}
+
+		if paid.IsPositive() {
+			treasury, err := settlement.Debit(tx, s.treasury, paid, "reward_funding", &marketID)
+			if err != nil {
+				return err
+			}
+			credited = append(credited, *treasury)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.listener != nil {
+		for i := range credited {
+			s.listener.PublishBalance(&credited[i])
+		}
+	}
+	return nil
+}
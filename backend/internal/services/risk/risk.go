@@ -0,0 +1,281 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/settlement"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// volumeWindow is the period that daily volume is measured over
+const volumeWindow = 24 * time.Hour
+
+var (
+	// ErrLimitExceeded is wrapped by every limit violation
+	ErrLimitExceeded = errors.New("risk limit exceeded")
+	// ErrInvalidLimit is wrapped by every rejected override
+	ErrInvalidLimit = errors.New("invalid risk limit")
+)
+
+// Limits cap what a single user can have at stake. A zero value disables
+// that limit.
+type Limits struct {
+	// Open and partially filled orders across all markets
+	MaxOpenOrders int `json:"max_open_orders"`
+	// Net shares in one outcome, counting resting orders as filled
+	MaxPosition decimal.Decimal `json:"max_position"`
+	// Resting orders at their limit price plus the cost of positions held,
+	// per market
+	MaxMarketNotional decimal.Decimal `json:"max_market_notional"`
+	// Traded value as maker or taker over the last 24 hours
+	MaxDailyVolume decimal.Decimal `json:"max_daily_volume"`
+}
+
+// apply overrides the limits with the fields an admin has set
+func (l Limits) apply(o *models.RiskLimit) Limits {
+	if o == nil {
+		return l
+	}
+	if o.MaxOpenOrders != nil {
+		l.MaxOpenOrders = *o.MaxOpenOrders
+	}
+	if o.MaxPosition != nil {
+		l.MaxPosition = *o.MaxPosition
+	}
+	if o.MaxMarketNotional != nil {
+		l.MaxMarketNotional = *o.MaxMarketNotional
+	}
+	if o.MaxDailyVolume != nil {
+		l.MaxDailyVolume = *o.MaxDailyVolume
+	}
+	return l
+}
+
+// Service enforces per-user exposure limits on new orders
+type Service struct {
+	db       *gorm.DB
+	defaults Limits
+}
+
+// NewService creates a new risk Service with the global default limits
+func NewService(db *gorm.DB, defaults Limits) *Service {
+	return &Service{db: db, defaults: defaults}
+}
+
+// UserLimits is the limits in force for a user and the override, if any,
+// they come from
+type UserLimits struct {
+	UserAddress string            `json:"user_address"`
+	Limits      Limits            `json:"limits"`
+	Defaults    Limits            `json:"defaults"`
+	Override    *models.RiskLimit `json:"override"`
+}
+
+// Get returns the limits that apply to a user
+func (s *Service) Get(userAddress string) (*UserLimits, error) {
+	override, err := s.override(s.db, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &UserLimits{
+		UserAddress: userAddress,
+		Limits:      s.defaults.apply(override),
+		Defaults:    s.defaults,
+		Override:    override,
+	}, nil
+}
+
+// Set replaces a user's override. Nil fields use the global default.
+func (s *Service) Set(userAddress string, limit models.RiskLimit, updatedBy string) (*UserLimits, error) {
+	if limit.MaxOpenOrders != nil && *limit.MaxOpenOrders < 0 {
+		return nil, fmt.Errorf("%w: max_open_orders must not be negative", ErrInvalidLimit)
+	}
+	for name, value := range map[string]*decimal.Decimal{
+		"max_position":        limit.MaxPosition,
+		"max_market_notional": limit.MaxMarketNotional,
+		"max_daily_volume":    limit.MaxDailyVolume,
+	} {
+		if value != nil && value.IsNegative() {
+			return nil, fmt.Errorf("%w: %s must not be negative", ErrInvalidLimit, name)
+		}
+	}
+
+	limit.UserAddress = userAddress
+	limit.UpdatedBy = updatedBy
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_address"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"max_open_orders", "max_position", "max_market_notional", "max_daily_volume", "updated_by", "updated_at",
+		}),
+	}).Create(&limit).Error; err != nil {
+		return nil, err
+	}
+	return s.Get(userAddress)
+}
+
+// Clear removes a user's override so the global defaults apply again
+func (s *Service) Clear(userAddress string) error {
+	return s.db.Delete(&models.RiskLimit{}, "user_address = ?", userAddress).Error
+}
+
+func (s *Service) override(tx *gorm.DB, userAddress string) (*models.RiskLimit, error) {
+	var limit models.RiskLimit
+	if err := tx.Where("user_address = ?", userAddress).First(&limit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// Check reports whether the user can place order, which has not been
+// saved yet. It runs inside the order's transaction and locks the user's
+// balance row so that concurrent orders from one user are checked in turn.
+//
+// Orders that shrink the user's worst-case position in the outcome are
+// exempt from the position and notional limits so that users over a
+// lowered limit can still close out.
+func (s *Service) Check(tx *gorm.DB, order *models.Order, now time.Time) error {
+	var balance models.UserBalance
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").
+		FirstOrCreate(&balance, models.UserBalance{UserAddress: order.UserAddress}).Error; err != nil {
+		return err
+	}
+
+	override, err := s.override(tx, order.UserAddress)
+	if err != nil {
+		return err
+	}
+	limits := s.defaults.apply(override)
+
+	if limits.MaxOpenOrders > 0 {
+		var open int64
+		if err := tx.Model(&models.Order{}).
+			Where("user_address = ? AND status IN ?", order.UserAddress,
+				[]models.OrderStatus{models.OrderStatusOpen, models.OrderStatusPartial}).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open >= int64(limits.MaxOpenOrders) {
+			return fmt.Errorf("%w: at most %d open orders", ErrLimitExceeded, limits.MaxOpenOrders)
+		}
+	}
+
+	notional, err := orderNotional(tx, order)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxPosition.IsPositive() || limits.MaxMarketNotional.IsPositive() {
+		before, after, err := worstPosition(tx, order)
+		if err != nil {
+			return err
+		}
+		if after.Abs().GreaterThan(before.Abs()) {
+			if limits.MaxPosition.IsPositive() && after.Abs().GreaterThan(limits.MaxPosition) {
+				return fmt.Errorf("%w: position in an outcome may not exceed %s shares", ErrLimitExceeded, limits.MaxPosition)
+			}
+			if limits.MaxMarketNotional.IsPositive() {
+				exposure, err := marketNotional(tx, order.UserAddress, order.MarketID)
+				if err != nil {
+					return err
+				}
+				if exposure.Add(notional).GreaterThan(limits.MaxMarketNotional) {
+					return fmt.Errorf("%w: notional in a market may not exceed %s", ErrLimitExceeded, limits.MaxMarketNotional)
+				}
+			}
+		}
+	}
+
+	if limits.MaxDailyVolume.IsPositive() {
+		var volume decimal.Decimal
+		if err := tx.Model(&models.Trade{}).
+			Select("COALESCE(SUM(price * quantity), 0)").
+			Where("(maker_address = ? OR taker_address = ?) AND created_at > ?",
+				order.UserAddress, order.UserAddress, now.Add(-volumeWindow)).
+			Scan(&volume).Error; err != nil {
+			return err
+		}
+		if volume.Add(notional).GreaterThan(limits.MaxDailyVolume) {
+			return fmt.Errorf("%w: volume over 24 hours may not exceed %s", ErrLimitExceeded, limits.MaxDailyVolume)
+		}
+	}
+
+	return nil
+}
+
+// worstPosition returns the user's net shares in the order's outcome if
+// every resting order on the order's side filled, before and after the
+// order itself fills
+func worstPosition(tx *gorm.DB, order *models.Order) (decimal.Decimal, decimal.Decimal, error) {
+	var position models.Position
+	if err := tx.Where("user_address = ? AND market_id = ? AND outcome = ?",
+		order.UserAddress, order.MarketID, order.Outcome).
+		Limit(1).Find(&position).Error; err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	var resting decimal.Decimal
+	if err := tx.Model(&models.Order{}).
+		Select("COALESCE(SUM(quantity - filled_quantity), 0)").
+		Where("user_address = ? AND market_id = ? AND outcome = ? AND side = ? AND status IN ?",
+			order.UserAddress, order.MarketID, order.Outcome, order.Side,
+			[]models.OrderStatus{models.OrderStatusOpen, models.OrderStatusPartial}).
+		Scan(&resting).Error; err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	if order.Side == models.OrderSideSell {
+		before := position.Shares.Sub(resting)
+		return before, before.Sub(order.Quantity), nil
+	}
+	before := position.Shares.Add(resting)
+	return before, before.Add(order.Quantity), nil
+}
+
+// orderNotional is what an order puts at stake: the price of each share
+// bought or sold out of the user's holdings, and 1 − price of each share
+// sold beyond them
+func orderNotional(tx *gorm.DB, order *models.Order) (decimal.Decimal, error) {
+	if order.Side == models.OrderSideBuy {
+		return order.Price.Mul(order.Quantity), nil
+	}
+	uncovered, err := settlement.Uncovered(tx, order)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	covered := order.Quantity.Sub(uncovered)
+	return covered.Mul(order.Price).Add(uncovered.Mul(decimal.NewFromInt(1).Sub(order.Price))), nil
+}
+
+// marketNotional is the value of a user's resting orders in a market, as
+// orderNotional counts it, plus the absolute cost of their positions in it.
+// Uncovered shares are filled last, so they are the last to leave the
+// remaining quantity.
+func marketNotional(tx *gorm.DB, userAddress string, marketID uint64) (decimal.Decimal, error) {
+	var orders decimal.Decimal
+	if err := tx.Model(&models.Order{}).
+		Select("COALESCE(SUM(price * (quantity - filled_quantity) + "+
+			"(1 - 2 * price) * LEAST(uncovered, quantity - filled_quantity)), 0)").
+		Where("user_address = ? AND market_id = ? AND status IN ?", userAddress, marketID,
+			[]models.OrderStatus{models.OrderStatusOpen, models.OrderStatusPartial}).
+		Scan(&orders).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	var positions decimal.Decimal
+	if err := tx.Model(&models.Position{}).
+		Select("COALESCE(SUM(ABS(cost)), 0)").
+		Where("user_address = ? AND market_id = ?", userAddress, marketID).
+		Scan(&positions).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	return orders.Add(positions), nil
+}
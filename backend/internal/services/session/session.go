@@ -0,0 +1,231 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/siwe"
+	"gorm.io/gorm"
+)
+
+// tokenType marks access tokens issued to wallet sessions so they cannot be
+// confused with other JWTs signed with the same secret
+const tokenType = "session"
+
+var (
+	ErrInvalidMessage = errors.New("invalid sign-in message")
+	ErrInvalidNonce   = errors.New("invalid or expired nonce")
+	ErrInvalidToken   = errors.New("invalid or expired refresh token")
+)
+
+// Service signs wallets in with Sign-In with Ethereum and issues session
+// tokens: a short-lived JWT access token and a rotating refresh token
+type Service struct {
+	db         *gorm.DB
+	secret     []byte
+	domain     string
+	chainID    int64
+	nonceTTL   time.Duration
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// Tokens is the result of signing in or refreshing
+type Tokens struct {
+	Address          string    `json:"address"`
+	AccessToken      string    `json:"access_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// Claims are the claims of an access token
+type Claims struct {
+	Type string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// NewService creates a new session Service. Sign-in messages must be for
+// domain and chainID.
+func NewService(db *gorm.DB, secret, domain string, chainID int64, nonceTTL, accessTTL, refreshTTL time.Duration) *Service {
+	return &Service{
+		db:         db,
+		secret:     []byte(secret),
+		domain:     domain,
+		chainID:    chainID,
+		nonceTTL:   nonceTTL,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// NewNonce issues a single-use nonce for a sign-in message
+func (s *Service) NewNonce() (*models.AuthNonce, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.AuthNonce{}).Error; err != nil {
+		return nil, err
+	}
+	record := &models.AuthNonce{Nonce: nonce, ExpiresAt: now.Add(s.nonceTTL)}
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// SignIn verifies a signed SIWE message, consumes its nonce and starts a
+// session for the signing wallet
+func (s *Service) SignIn(text, signature string) (*Tokens, error) {
+	msg, err := siwe.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if msg.Domain != s.domain {
+		return nil, fmt.Errorf("%w: domain must be %s", ErrInvalidMessage, s.domain)
+	}
+	if msg.ChainID != s.chainID {
+		return nil, fmt.Errorf("%w: chain id must be %d", ErrInvalidMessage, s.chainID)
+	}
+	now := time.Now()
+	if err := msg.Valid(now); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if err := siwe.Verify(text, signature, msg.Address); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	var tokens *Tokens
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("nonce = ? AND expires_at > ?", msg.Nonce, now).Delete(&models.AuthNonce{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidNonce
+		}
+
+		tokens, err = s.issue(tx, msg.Address, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Refresh revokes a refresh token and issues a new session for its wallet
+func (s *Service) Refresh(refreshToken string) (*Tokens, error) {
+	now := time.Now()
+
+	var tokens *Tokens
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").
+			Where("token_hash = ?", hashToken(refreshToken)).
+			First(&token).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if token.RevokedAt != nil || !now.Before(token.ExpiresAt) {
+			return ErrInvalidToken
+		}
+
+		if err := tx.Model(&token).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = s.issue(tx, token.UserAddress, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke ends the session of a refresh token. Access tokens already issued
+// stay valid until they expire.
+func (s *Service) Revoke(refreshToken string) error {
+	return s.db.Model(&models.RefreshToken{}).
+		Where("token_hash = ? AND revoked_at IS NULL", hashToken(refreshToken)).
+		Update("revoked_at", time.Now()).Error
+}
+
+// issue creates an access token and a stored refresh token for address
+func (s *Service) issue(tx *gorm.DB, address string, now time.Time) (*Tokens, error) {
+	expiresAt := now.Add(s.accessTTL)
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Type: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   address,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	record := models.RefreshToken{
+		TokenHash:   hashToken(refreshToken),
+		UserAddress: address,
+		ExpiresAt:   now.Add(s.refreshTTL),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		Address:          address,
+		AccessToken:      accessToken,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// ParseAccessToken validates a session access token and returns the wallet
+// address it was issued to
+func ParseAccessToken(secret, token string) (string, error) {
+	var claims Claims
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid {
+		return "", errors.New("invalid token")
+	}
+	if claims.Type != tokenType || claims.Subject == "" {
+		return "", errors.New("not a session token")
+	}
+	return claims.Subject, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
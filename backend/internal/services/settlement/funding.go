@@ -0,0 +1,43 @@
+package settlement
+
+import (
+	"errors"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrInsufficientFunds is returned when a platform account cannot cover a
+// payment it funds
+var ErrInsufficientFunds = errors.New("insufficient funds in funding account")
+
+// Debit takes amount from an account's available balance and logs the
+// change as changeType. Platform accounts use it to fund what they pay out
+// in the same transaction as the payout.
+func Debit(tx *gorm.DB, address string, amount decimal.Decimal, changeType string, referenceID *uint64) (*models.UserBalance, error) {
+	var balance models.UserBalance
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").
+		FirstOrCreate(&balance, models.UserBalance{UserAddress: address}).Error; err != nil {
+		return nil, err
+	}
+	if balance.Available.LessThan(amount) {
+		return nil, ErrInsufficientFunds
+	}
+
+	balance.Available = balance.Available.Sub(amount)
+	if err := tx.Save(&balance).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(&models.BalanceLog{
+		UserAddress:  address,
+		ChangeType:   changeType,
+		Amount:       amount.Neg(),
+		BalanceAfter: balance.Available,
+		ReferenceID:  referenceID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
@@ -0,0 +1,88 @@
+package settlement
+
+import (
+	"github.com/prediction-market/backend/internal/eip712"
+	"github.com/prediction-market/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// SignedOrder is an order as its owner signed it, with price and size in
+// eip712.OrderDecimals fixed point so it can be verified on-chain
+type SignedOrder struct {
+	OrderID   uint64           `json:"order_id"`
+	Maker     string           `json:"maker"`
+	MarketID  uint64           `json:"market_id"`
+	Outcome   uint8            `json:"outcome"`
+	Side      models.OrderSide `json:"side"`
+	Price     string           `json:"price"`
+	Size      string           `json:"size"`
+	Nonce     string           `json:"nonce"`
+	Expiry    int64            `json:"expiry"`
+	Signature string           `json:"signature"`
+}
+
+// TradePayload is what an operator submits to settle a trade on-chain: the
+// fill in fixed point and the signed orders that authorised it. Maker is
+// nil when the AMM filled the order.
+type TradePayload struct {
+	Trade  models.Trade `json:"trade"`
+	Shares string       `json:"shares"`
+	Cost   string       `json:"cost"`
+	Taker  *SignedOrder `json:"taker"`
+	Maker  *SignedOrder `json:"maker"`
+}
+
+// BuildTradePayload assembles the settlement payload of a trade
+func BuildTradePayload(db *gorm.DB, trade *models.Trade) (*TradePayload, error) {
+	shares, err := eip712.Units(trade.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	cost, err := eip712.Units(trade.Price.Mul(trade.Quantity).Truncate(eip712.OrderDecimals))
+	if err != nil {
+		return nil, err
+	}
+	payload := &TradePayload{Trade: *trade, Shares: shares.String(), Cost: cost.String()}
+
+	if payload.Taker, err = signedOrder(db, trade.TakerOrderID); err != nil {
+		return nil, err
+	}
+	if trade.MakerOrderID != 0 {
+		if payload.Maker, err = signedOrder(db, trade.MakerOrderID); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+func signedOrder(db *gorm.DB, orderID uint64) (*SignedOrder, error) {
+	var order models.Order
+	if err := db.First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+
+	price, err := eip712.Units(order.Price)
+	if err != nil {
+		return nil, err
+	}
+	size, err := eip712.Units(order.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	signed := &SignedOrder{
+		OrderID:   order.ID,
+		Maker:     order.UserAddress,
+		MarketID:  order.MarketID,
+		Outcome:   order.Outcome,
+		Side:      order.Side,
+		Price:     price.String(),
+		Size:      size.String(),
+		Expiry:    order.Expiry,
+		Signature: order.Signature,
+	}
+	// Orders placed before signing was required have no nonce
+	if order.Nonce != nil {
+		signed.Nonce = *order.Nonce
+	}
+	return signed, nil
+}
@@ -0,0 +1,264 @@
+package settlement
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// payoutTolerance absorbs rounding in vectors such as 1/3, 1/3, 1/3
+var payoutTolerance = decimal.New(1, -6)
+
+// ValidatePayouts checks that a payout vector has one entry per outcome,
+// each between 0 and 1, summing to 1
+func ValidatePayouts(payouts []decimal.Decimal, outcomes int) error {
+	if len(payouts) != outcomes {
+		return fmt.Errorf("payouts must have %d entries", outcomes)
+	}
+
+	sum := decimal.Zero
+	for _, p := range payouts {
+		if p.IsNegative() || p.GreaterThan(decimal.NewFromInt(1)) {
+			return errors.New("each payout must be between 0 and 1")
+		}
+		sum = sum.Add(p)
+	}
+
+	if sum.Sub(decimal.NewFromInt(1)).Abs().GreaterThan(payoutTolerance) {
+		return errors.New("payouts must sum to 1")
+	}
+	return nil
+}
+
+// RecordTrade moves outcome shares from the seller to the buyer of a trade,
+// books the notional against each side's position cost and adds it to the
+// market's volume. It returns the resulting change in the outcome's open
+// interest.
+func RecordTrade(tx *gorm.DB, trade *models.Trade, takerSide models.OrderSide) (decimal.Decimal, error) {
+	buyer, seller := trade.TakerAddress, trade.MakerAddress
+	if takerSide == models.OrderSideSell {
+		buyer, seller = seller, buyer
+	}
+
+	notional := trade.Price.Mul(trade.Quantity)
+
+	if err := tx.Model(&models.Market{}).Where("id = ?", trade.MarketID).
+		UpdateColumn("volume", gorm.Expr("volume + ?", notional)).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	buyerDelta, err := addPosition(tx, buyer, trade.MarketID, trade.Outcome, trade.Quantity, notional)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	sellerDelta, err := addPosition(tx, seller, trade.MarketID, trade.Outcome, trade.Quantity.Neg(), notional.Neg())
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return buyerDelta.Add(sellerDelta), nil
+}
+
+// Uncovered returns how many shares of a sell order exceed what the user
+// holds in the outcome, less what their resting sells may already sell
+func Uncovered(tx *gorm.DB, order *models.Order) (decimal.Decimal, error) {
+	var position models.Position
+	if err := tx.Where("user_address = ? AND market_id = ? AND outcome = ?",
+		order.UserAddress, order.MarketID, order.Outcome).
+		Limit(1).Find(&position).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	var resting decimal.Decimal
+	if err := tx.Model(&models.Order{}).
+		Select("COALESCE(SUM(quantity - filled_quantity), 0)").
+		Where("user_address = ? AND market_id = ? AND outcome = ? AND side = ? AND status IN ?",
+			order.UserAddress, order.MarketID, order.Outcome, models.OrderSideSell,
+			[]models.OrderStatus{models.OrderStatusOpen, models.OrderStatusPartial}).
+		Scan(&resting).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	held := decimal.Max(position.Shares.Sub(resting), decimal.Zero)
+	return decimal.Max(order.Quantity.Sub(held), decimal.Zero), nil
+}
+
+// addPosition upserts a position, adding shares and cost to any existing
+// row, and returns the change in the position's long shares
+func addPosition(tx *gorm.DB, user string, marketID uint64, outcome uint8, shares, cost decimal.Decimal) (decimal.Decimal, error) {
+	position := models.Position{
+		UserAddress: user,
+		MarketID:    marketID,
+		Outcome:     outcome,
+		Shares:      shares,
+		Cost:        cost,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_address"}, {Name: "market_id"}, {Name: "outcome"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"shares":     gorm.Expr("positions.shares + EXCLUDED.shares"),
+			"cost":       gorm.Expr("positions.cost + EXCLUDED.cost"),
+			"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}, clause.Returning{Columns: []clause.Column{{Name: "shares"}}}).Create(&position).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	// position.Shares now holds the updated total
+	after := position.Shares
+	before := after.Sub(shares)
+	return decimal.Max(after, decimal.Zero).Sub(decimal.Max(before, decimal.Zero)), nil
+}
+
+// Result lists what closing out a market changed: the orders it cancelled
+// and the balances it updated
+type Result struct {
+	Orders   []models.Order
+	Balances []models.UserBalance
+}
+
+// SettleMarket closes out a resolved market. payouts[i] is the amount paid
+// per share of outcome i+1. Resting orders are cancelled, funds locked by
+// orders are released, and every position is credited
+// shares × payout − cost. Short positions (negative shares) pay their
+// liability out of the collateral their sells locked.
+func SettleMarket(tx *gorm.DB, marketID uint64, payouts []decimal.Decimal) (*Result, error) {
+	cancelled, deltas, err := releaseOrders(tx, marketID)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []models.Position
+	if err := tx.Where("market_id = ?", marketID).Find(&positions).Error; err != nil {
+		return nil, err
+	}
+
+	for _, p := range positions {
+		payout := decimal.Zero
+		if i := int(p.Outcome) - 1; i >= 0 && i < len(payouts) {
+			payout = payouts[i]
+		}
+		d := deltas[p.UserAddress]
+		d.available = d.available.Add(p.Shares.Mul(payout).Truncate(6)).Sub(p.Cost)
+		deltas[p.UserAddress] = d
+	}
+
+	balances, err := applyDeltas(tx, marketID, deltas)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Orders: cancelled, Balances: balances}, nil
+}
+
+// CancelMarket unwinds a cancelled market: resting orders are cancelled and
+// every user gets back the funds locked by their orders. Trades carry
+// no profit or loss, so positions are left as a record only.
+func CancelMarket(tx *gorm.DB, marketID uint64) (*Result, error) {
+	cancelled, deltas, err := releaseOrders(tx, marketID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := applyDeltas(tx, marketID, deltas)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Orders: cancelled, Balances: balances}, nil
+}
+
+// balanceDelta is the change to apply to a user's balance
+type balanceDelta struct {
+	available decimal.Decimal
+	locked    decimal.Decimal
+}
+
+// releaseOrders cancels the market's resting orders and returns them along
+// with, per user, the funds still locked by buy orders and uncovered sells
+// moved back to available
+func releaseOrders(tx *gorm.DB, marketID uint64) ([]models.Order, map[string]balanceDelta, error) {
+	var orders []models.Order
+	if err := tx.Where("market_id = ? AND (side = ? OR uncovered > 0)", marketID, models.OrderSideBuy).
+		Find(&orders).Error; err != nil {
+		return nil, nil, err
+	}
+
+	deltas := make(map[string]balanceDelta)
+	for _, o := range orders {
+		// Collateral of the filled quantity stays locked until settlement;
+		// that of the unfilled quantity of cancelled orders was already
+		// released
+		held := o.LockedFor(o.FilledQuantity)
+		if o.Status == models.OrderStatusOpen || o.Status == models.OrderStatusPartial {
+			held = o.LockedFor(o.Quantity)
+		}
+		d := deltas[o.UserAddress]
+		d.available = d.available.Add(held)
+		d.locked = d.locked.Sub(held)
+		deltas[o.UserAddress] = d
+	}
+
+	live := []models.OrderStatus{models.OrderStatusOpen, models.OrderStatusPartial}
+	var cancelled []models.Order
+	if err := tx.Where("market_id = ? AND status IN ?", marketID, live).
+		Find(&cancelled).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Model(&models.Order{}).
+		Where("market_id = ? AND status IN ?", marketID, live).
+		Update("status", models.OrderStatusCancelled).Error; err != nil {
+		return nil, nil, err
+	}
+	for i := range cancelled {
+		cancelled[i].Status = models.OrderStatusCancelled
+	}
+
+	return cancelled, deltas, nil
+}
+
+// applyDeltas writes balance changes and a settlement log entry per user
+// and returns the updated balances
+func applyDeltas(tx *gorm.DB, marketID uint64, deltas map[string]balanceDelta) ([]models.UserBalance, error) {
+	// Lock balance rows in a stable order to avoid deadlocks
+	users := make([]string, 0, len(deltas))
+	for user := range deltas {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	balances := make([]models.UserBalance, 0, len(users))
+	for _, user := range users {
+		d := deltas[user]
+		if d.available.IsZero() && d.locked.IsZero() {
+			continue
+		}
+
+		var balance models.UserBalance
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").
+			FirstOrCreate(&balance, models.UserBalance{UserAddress: user}).Error; err != nil {
+			return nil, err
+		}
+
+		balance.Available = balance.Available.Add(d.available)
+		balance.Locked = balance.Locked.Add(d.locked)
+		if err := tx.Save(&balance).Error; err != nil {
+			return nil, err
+		}
+
+		if err := tx.Create(&models.BalanceLog{
+			UserAddress:  user,
+			ChangeType:   "settlement",
+			Amount:       d.available,
+			BalanceAfter: balance.Available,
+			ReferenceID:  &marketID,
+		}).Error; err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, nil
+}
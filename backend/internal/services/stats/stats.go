@@ -0,0 +1,246 @@
+package stats
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/prediction-market/backend/internal/models"
+	"github.com/prediction-market/backend/internal/services/orderbook"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// window is the span of the rolling 24h statistics
+const window = 24 * time.Hour
+
+type outcomeKey struct {
+	MarketID uint64
+	Outcome  uint8
+}
+
+// bucket aggregates the trades of one minute
+type bucket struct {
+	Minute int64
+	Volume decimal.Decimal
+	Open   decimal.Decimal
+	Close  decimal.Decimal
+}
+
+// outcomeState is the running statistics of one outcome
+type outcomeState struct {
+	TotalVolume  decimal.Decimal
+	LastPrice    decimal.Decimal
+	OpenInterest decimal.Decimal
+	// Minute buckets inside the rolling window, oldest first
+	Buckets []bucket
+	// Last price before the oldest bucket, if any trade preceded the window
+	RefPrice *decimal.Decimal
+}
+
+// Service maintains per-outcome trading statistics in memory. It is loaded
+// from the database at startup and then updated incrementally as trades
+// are recorded. Best bid and ask are read live from the order books.
+type Service struct {
+	db  *gorm.DB
+	obm *orderbook.OrderBookManager
+
+	mu       sync.Mutex
+	outcomes map[outcomeKey]*outcomeState
+}
+
+// NewService creates a new stats Service
+func NewService(db *gorm.DB, obm *orderbook.OrderBookManager) *Service {
+	return &Service{
+		db:       db,
+		obm:      obm,
+		outcomes: make(map[outcomeKey]*outcomeState),
+	}
+}
+
+// Load rebuilds the statistics from trades and positions
+func (s *Service) Load() error {
+	now := time.Now()
+	since := now.Add(-window)
+	outcomes := make(map[outcomeKey]*outcomeState)
+	state := func(marketID uint64, outcome uint8) *outcomeState {
+		key := outcomeKey{MarketID: marketID, Outcome: outcome}
+		if outcomes[key] == nil {
+			outcomes[key] = &outcomeState{}
+		}
+		return outcomes[key]
+	}
+
+	var totals []struct {
+		MarketID uint64
+		Outcome  uint8
+		Volume   decimal.Decimal
+	}
+	if err := s.db.Model(&models.Trade{}).
+		Select("market_id, outcome, SUM(price * quantity) AS volume").
+		Group("market_id, outcome").
+		Scan(&totals).Error; err != nil {
+		return err
+	}
+	for _, t := range totals {
+		state(t.MarketID, t.Outcome).TotalVolume = t.Volume
+	}
+
+	var last []models.Trade
+	if err := s.db.Raw(`SELECT DISTINCT ON (market_id, outcome) * FROM trades
+		ORDER BY market_id, outcome, created_at DESC, id DESC`).
+		Scan(&last).Error; err != nil {
+		return err
+	}
+	for _, t := range last {
+		state(t.MarketID, t.Outcome).LastPrice = t.Price
+	}
+
+	var before []models.Trade
+	if err := s.db.Raw(`SELECT DISTINCT ON (market_id, outcome) * FROM trades WHERE created_at < ?
+		ORDER BY market_id, outcome, created_at DESC, id DESC`, since).
+		Scan(&before).Error; err != nil {
+		return err
+	}
+	for _, t := range before {
+		price := t.Price
+		state(t.MarketID, t.Outcome).RefPrice = &price
+	}
+
+	var recent []models.Trade
+	if err := s.db.Where("created_at >= ?", since).
+		Order("created_at, id").
+		Find(&recent).Error; err != nil {
+		return err
+	}
+	for _, t := range recent {
+		state(t.MarketID, t.Outcome).addToWindow(t)
+	}
+
+	var interest []struct {
+		MarketID uint64
+		Outcome  uint8
+		Shares   decimal.Decimal
+	}
+	if err := s.db.Model(&models.Position{}).
+		Select("market_id, outcome, SUM(shares) AS shares").
+		Where("shares > 0").
+		Group("market_id, outcome").
+		Scan(&interest).Error; err != nil {
+		return err
+	}
+	for _, i := range interest {
+		state(i.MarketID, i.Outcome).OpenInterest = i.Shares
+	}
+
+	s.mu.Lock()
+	s.outcomes = outcomes
+	s.mu.Unlock()
+	return nil
+}
+
+// RecordTrade adds a committed trade to the statistics. openInterestDelta
+// is the change reported by settlement.RecordTrade.
+func (s *Service) RecordTrade(trade models.Trade, openInterestDelta decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := outcomeKey{MarketID: trade.MarketID, Outcome: trade.Outcome}
+	st := s.outcomes[key]
+	if st == nil {
+		st = &outcomeState{}
+		s.outcomes[key] = st
+	}
+
+	st.TotalVolume = st.TotalVolume.Add(trade.Price.Mul(trade.Quantity))
+	st.LastPrice = trade.Price
+	st.OpenInterest = st.OpenInterest.Add(openInterestDelta)
+	st.addToWindow(trade)
+}
+
+func (st *outcomeState) addToWindow(trade models.Trade) {
+	minute := trade.CreatedAt.Unix() / 60
+	notional := trade.Price.Mul(trade.Quantity)
+
+	if n := len(st.Buckets); n > 0 && st.Buckets[n-1].Minute >= minute {
+		st.Buckets[n-1].Volume = st.Buckets[n-1].Volume.Add(notional)
+		st.Buckets[n-1].Close = trade.Price
+		return
+	}
+	st.Buckets = append(st.Buckets, bucket{Minute: minute, Volume: notional, Open: trade.Price, Close: trade.Price})
+}
+
+// prune drops buckets that have left the window, remembering the price
+// they closed at
+func (st *outcomeState) prune(now time.Time) {
+	cutoff := now.Add(-window).Unix() / 60
+	dropped := 0
+	for dropped < len(st.Buckets) && st.Buckets[dropped].Minute < cutoff {
+		dropped++
+	}
+	if dropped == 0 {
+		return
+	}
+
+	closePrice := st.Buckets[dropped-1].Close
+	st.RefPrice = &closePrice
+	st.Buckets = append(st.Buckets[:0], st.Buckets[dropped:]...)
+}
+
+// Market returns the statistics of a market
+func (s *Service) Market(market *models.Market) models.MarketWithStats {
+	var names []string
+	_ = json.Unmarshal(market.Outcomes, &names)
+
+	result := models.MarketWithStats{
+		Market:       *market,
+		OutcomeStats: make([]models.OutcomeStats, 0, len(names)),
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for i := range names {
+		outcome := uint8(i + 1)
+		stats := models.OutcomeStats{Outcome: outcome}
+
+		if st := s.outcomes[outcomeKey{MarketID: market.ID, Outcome: outcome}]; st != nil {
+			st.prune(now)
+			stats.TotalVolume = st.TotalVolume
+			stats.LastPrice = st.LastPrice
+			stats.OpenInterest = st.OpenInterest
+			for _, b := range st.Buckets {
+				stats.Volume24h = stats.Volume24h.Add(b.Volume)
+			}
+			switch {
+			case st.RefPrice != nil:
+				stats.PriceChange24h = st.LastPrice.Sub(*st.RefPrice)
+			case len(st.Buckets) > 0:
+				stats.PriceChange24h = st.LastPrice.Sub(st.Buckets[0].Open)
+			}
+		}
+		result.OutcomeStats = append(result.OutcomeStats, stats)
+	}
+	s.mu.Unlock()
+
+	for i := range result.OutcomeStats {
+		stats := &result.OutcomeStats[i]
+		if ob, ok := s.obm.Get(market.ID, stats.Outcome); ok {
+			if bid, ok := ob.BestBid(); ok {
+				stats.BestBid = &bid
+			}
+			if ask, ok := ob.BestAsk(); ok {
+				stats.BestAsk = &ask
+			}
+		}
+
+		result.TotalVolume = result.TotalVolume.Add(stats.TotalVolume)
+		result.Volume24h = result.Volume24h.Add(stats.Volume24h)
+		result.OpenInterest = result.OpenInterest.Add(stats.OpenInterest)
+	}
+	if len(result.OutcomeStats) > 0 {
+		result.LastPrice = result.OutcomeStats[0].LastPrice
+		result.PriceChange24h = result.OutcomeStats[0].PriceChange24h
+	}
+
+	return result
+}
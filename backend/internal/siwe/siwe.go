@@ -0,0 +1,166 @@
+// Package siwe parses and verifies Sign-In with Ethereum (EIP-4361)
+// messages.
+package siwe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prediction-market/backend/internal/eip712"
+)
+
+const headerSuffix = " wants you to sign in with your Ethereum account:"
+
+// Message is a parsed EIP-4361 message
+type Message struct {
+	Domain         string
+	Address        string // lowercase
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// Parse parses the text of a SIWE message:
+//
+//	example.com wants you to sign in with your Ethereum account:
+//	0xAbC...
+//
+//	Optional statement
+//
+//	URI: https://example.com
+//	Version: 1
+//	Chain ID: 1
+//	Nonce: 32891756
+//	Issued At: 2021-09-30T16:25:24Z
+//	Expiration Time: ... (optional)
+//	Not Before: ...      (optional)
+//	Request ID: ...      (optional)
+//	Resources:           (optional)
+//	- https://example.com/a
+func Parse(text string) (*Message, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return nil, errors.New("message too short")
+	}
+
+	domain, ok := strings.CutSuffix(lines[0], headerSuffix)
+	if !ok || domain == "" {
+		return nil, errors.New("invalid message header")
+	}
+	msg := &Message{Domain: domain}
+
+	if !eip712.IsAddress(lines[1]) {
+		return nil, errors.New("invalid address")
+	}
+	msg.Address = strings.ToLower(lines[1])
+
+	if lines[2] != "" {
+		return nil, errors.New("expected empty line after address")
+	}
+	i := 3
+	if !strings.HasPrefix(lines[i], "URI: ") {
+		msg.Statement = lines[i]
+		i++
+		if i >= len(lines) || lines[i] != "" {
+			return nil, errors.New("expected empty line after statement")
+		}
+		i++
+	}
+
+	fields := make(map[string]string)
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "Resources:" {
+			for i++; i < len(lines); i++ {
+				resource, ok := strings.CutPrefix(lines[i], "- ")
+				if !ok {
+					return nil, fmt.Errorf("invalid resource line %q", lines[i])
+				}
+				msg.Resources = append(msg.Resources, resource)
+			}
+			break
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		if _, dup := fields[key]; dup {
+			return nil, fmt.Errorf("duplicate field %q", key)
+		}
+		fields[key] = value
+	}
+
+	var err error
+	for _, key := range []string{"URI", "Version", "Chain ID", "Nonce", "Issued At"} {
+		if fields[key] == "" {
+			return nil, fmt.Errorf("missing %s", key)
+		}
+	}
+	msg.URI = fields["URI"]
+	msg.Version = fields["Version"]
+	if msg.Version != "1" {
+		return nil, errors.New("unsupported version")
+	}
+	if msg.ChainID, err = strconv.ParseInt(fields["Chain ID"], 10, 64); err != nil {
+		return nil, errors.New("invalid chain id")
+	}
+	msg.Nonce = fields["Nonce"]
+	if msg.IssuedAt, err = time.Parse(time.RFC3339, fields["Issued At"]); err != nil {
+		return nil, errors.New("invalid issued at")
+	}
+	if msg.ExpirationTime, err = optionalTime(fields, "Expiration Time"); err != nil {
+		return nil, err
+	}
+	if msg.NotBefore, err = optionalTime(fields, "Not Before"); err != nil {
+		return nil, err
+	}
+	msg.RequestID = fields["Request ID"]
+
+	return msg, nil
+}
+
+func optionalTime(fields map[string]string, key string) (*time.Time, error) {
+	value, ok := fields[key]
+	if !ok {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", strings.ToLower(key))
+	}
+	return &t, nil
+}
+
+// Valid reports whether the message may be used at now
+func (m *Message) Valid(now time.Time) error {
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return errors.New("message expired")
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return errors.New("message not yet valid")
+	}
+	return nil
+}
+
+// Verify checks that signature is the message's address signing text with
+// personal_sign
+func Verify(text, signature, address string) error {
+	signer, err := eip712.Recover(eip712.PersonalHash([]byte(text)), signature)
+	if err != nil {
+		return err
+	}
+	if signer != address {
+		return errors.New("signature does not match address")
+	}
+	return nil
+}
@@ -13,6 +13,8 @@ export function MarketCard({ market }: MarketCardProps) {
   const statusColors: Record<string, string> = {
     active: 'bg-green-100 text-green-800',
     pending: 'bg-yellow-100 text-yellow-800',
+    proposed: 'bg-purple-100 text-purple-800',
+    disputed: 'bg-orange-100 text-orange-800',
     resolved: 'bg-blue-100 text-blue-800',
     cancelled: 'bg-red-100 text-red-800',
   };
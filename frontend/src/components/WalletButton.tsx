@@ -1,6 +1,7 @@
 import { ConnectButton } from '@rainbow-me/rainbowkit';
 import { useAccount, useDisconnect, useBalance } from 'wagmi';
 import { useState, useRef, useEffect } from 'react';
+import { signOut } from '../services/api';
 
 // Fallback copy function for HTTP
 function copyToClipboard(text: string): Promise<boolean> {
@@ -128,6 +129,7 @@ export function WalletButton() {
           <div className="p-3 border-t border-gray-100">
             <button
               onClick={() => {
+                signOut().catch(() => {});
                 disconnect();
                 setShowModal(false);
               }}
@@ -1,4 +1,8 @@
 import axios from 'axios';
+import { getAddress, parseUnits, type Address } from 'viem';
+import { createSiweMessage } from 'viem/siwe';
+import { signMessage, signTypedData } from 'wagmi/actions';
+import { config } from '../config/wagmi';
 
 const api = axios.create({
   baseURL: import.meta.env.VITE_API_URL || 'http://localhost:8080/api',
@@ -8,11 +12,30 @@ export interface Market {
   id: number;
   question: string;
   description: string;
+  category: string;
+  tags: string[] | null;
   outcomes: string[];
   end_time: string;
   resolution_time: string;
   resolved_outcome: number | null;
-  status: 'pending' | 'active' | 'resolved' | 'cancelled';
+  status: 'pending' | 'active' | 'proposed' | 'disputed' | 'resolved' | 'cancelled';
+  total_volume: string;
+  volume_24h: string;
+  last_price: string;
+  price_change_24h: string;
+  open_interest: string;
+  outcome_stats: OutcomeStats[];
+}
+
+export interface OutcomeStats {
+  outcome: number;
+  total_volume: string;
+  volume_24h: string;
+  last_price: string;
+  price_change_24h: string;
+  best_bid: string | null;
+  best_ask: string | null;
+  open_interest: string;
 }
 
 export interface Order {
@@ -25,6 +48,9 @@ export interface Order {
   quantity: string;
   filled_quantity: string;
   status: 'open' | 'filled' | 'partial' | 'cancelled';
+  nonce: string | null;
+  expiry: number;
+  client_order_id: string | null;
   created_at: string;
 }
 
@@ -36,6 +62,16 @@ export interface Trade {
   created_at: string;
 }
 
+export interface Candle {
+  start_time: string;
+  open: string;
+  high: string;
+  low: string;
+  close: string;
+  volume: string;
+  trades: number;
+}
+
 export interface PriceLevel {
   price: string;
   quantity: string;
@@ -46,33 +82,211 @@ export interface OrderBookData {
   sells: PriceLevel[];
 }
 
+export interface Page<T> {
+  data: T[];
+  next_cursor: string;
+}
+
+export interface PageParams {
+  cursor?: string;
+  limit?: number;
+  from?: string;
+  to?: string;
+}
+
 export const marketApi = {
-  list: () => api.get<Market[]>('/markets'),
+  list: (params?: PageParams) => api.get<Page<Market>>('/markets', { params }),
   get: (id: number) => api.get<Market>(`/markets/${id}`),
-  getTrades: (id: number) => api.get<Trade[]>(`/markets/${id}/trades`),
+  getTrades: (id: number, params?: PageParams) =>
+    api.get<Page<Trade>>(`/markets/${id}/trades`, { params }),
+  getCandles: (id: number, params: {
+    outcome: number;
+    interval: '1m' | '5m' | '1h' | '1d';
+    from?: string;
+    to?: string;
+  }) => api.get<Candle[]>(`/markets/${id}/candles`, { params }),
   getOrderBook: (id: number, outcome: number) =>
     api.get<OrderBookData>(`/markets/${id}/orderbook`, { params: { outcome } }),
 };
 
+export interface Session {
+  address: string;
+  access_token: string;
+  expires_at: string;
+  refresh_token: string;
+  refresh_expires_at: string;
+}
+
+export const authApi = {
+  getNonce: () => api.get<{ nonce: string; expires_at: string }>('/auth/nonce'),
+  signIn: (message: string, signature: string) =>
+    api.post<Session>('/auth/siwe', { message, signature }),
+  refresh: (refreshToken: string) =>
+    api.post<Session>('/auth/refresh', { refresh_token: refreshToken }),
+  logout: (refreshToken: string) =>
+    api.post('/auth/logout', { refresh_token: refreshToken }),
+};
+
+const SESSION_KEY = 'session';
+
+function loadSession(): Session | null {
+  const raw = localStorage.getItem(SESSION_KEY);
+  return raw ? (JSON.parse(raw) as Session) : null;
+}
+
+function saveSession(session: Session | null) {
+  if (session) localStorage.setItem(SESSION_KEY, JSON.stringify(session));
+  else localStorage.removeItem(SESSION_KEY);
+}
+
+// Access tokens are renewed this long before they expire
+const RENEW_MARGIN_MS = 30_000;
+
+// getAccessToken returns a session access token for the wallet, refreshing
+// the session or signing in with Ethereum when there is no usable one
+async function getAccessToken(walletAddress: string): Promise<string> {
+  let session = loadSession();
+  if (session && session.address !== walletAddress.toLowerCase()) {
+    session = null;
+  }
+
+  const now = Date.now();
+  if (session && new Date(session.expires_at).getTime() - RENEW_MARGIN_MS > now) {
+    return session.access_token;
+  }
+
+  if (session && new Date(session.refresh_expires_at).getTime() > now) {
+    try {
+      const res = await authApi.refresh(session.refresh_token);
+      saveSession(res.data);
+      return res.data.access_token;
+    } catch {
+      // Fall through to signing in again
+    }
+  }
+
+  const { data: { nonce } } = await authApi.getNonce();
+  const message = createSiweMessage({
+    address: getAddress(walletAddress),
+    chainId: config.chains[0].id,
+    domain: window.location.host,
+    uri: window.location.origin,
+    version: '1',
+    statement: 'Sign in to Prediction Market',
+    nonce,
+    issuedAt: new Date(),
+  });
+  const signature = await signMessage(config, { account: walletAddress as Address, message });
+  const res = await authApi.signIn(message, signature);
+  saveSession(res.data);
+  return res.data.access_token;
+}
+
+// signOut revokes the stored session
+export async function signOut() {
+  const session = loadSession();
+  saveSession(null);
+  if (session) await authApi.logout(session.refresh_token);
+}
+
+async function authHeaders(walletAddress: string) {
+  return { Authorization: `Bearer ${await getAccessToken(walletAddress)}` };
+}
+
+// EIP-712 order the backend and settlement verify. Price and size are
+// fixed point with 6 decimals; expiry is a unix time, 0 for none.
+const orderDomain = {
+  name: 'PredictionMarket',
+  version: '1',
+  chainId: config.chains[0].id,
+} as const;
+
+const orderTypes = {
+  Order: [
+    { name: 'maker', type: 'address' },
+    { name: 'marketId', type: 'uint256' },
+    { name: 'outcome', type: 'uint8' },
+    { name: 'side', type: 'string' },
+    { name: 'price', type: 'uint256' },
+    { name: 'size', type: 'uint256' },
+    { name: 'nonce', type: 'uint256' },
+    { name: 'expiry', type: 'uint256' },
+  ],
+} as const;
+
+export interface OrderInput {
+  market_id: number;
+  outcome: number;
+  side: 'buy' | 'sell';
+  price: string;
+  quantity: string;
+  client_order_id?: string;
+}
+
+async function signOrder(data: OrderInput, walletAddress: string) {
+  const nonce = BigInt(`0x${Array.from(crypto.getRandomValues(new Uint8Array(16)),
+    (b) => b.toString(16).padStart(2, '0')).join('')}`);
+  const expiry = 0n;
+  const signature = await signTypedData(config, {
+    account: walletAddress as Address,
+    domain: orderDomain,
+    types: orderTypes,
+    primaryType: 'Order',
+    message: {
+      maker: walletAddress as Address,
+      marketId: BigInt(data.market_id),
+      outcome: data.outcome,
+      side: data.side,
+      price: parseUnits(data.price, 6),
+      size: parseUnits(data.quantity, 6),
+      nonce,
+      expiry,
+    },
+  });
+  return { ...data, nonce: nonce.toString(), expiry: Number(expiry), signature };
+}
+
 export const orderApi = {
-  place: (data: {
-    market_id: number;
-    outcome: number;
-    side: 'buy' | 'sell';
-    price: string;
-    quantity: string;
-  }, walletAddress: string) =>
-    api.post('/orders', data, {
-      headers: { 'X-Wallet-Address': walletAddress },
-    }),
+  place: async (data: OrderInput, walletAddress: string) => {
+    const signed = await signOrder(data, walletAddress);
+    const headers = await authHeaders(walletAddress);
+    return api.post('/orders', signed, { headers });
+  },
   cancel: (id: number, walletAddress: string) =>
-    api.delete(`/orders/${id}`, {
-      headers: { 'X-Wallet-Address': walletAddress },
-    }),
-  getUserOrders: (walletAddress: string) =>
-    api.get<Order[]>('/user/orders', {
-      headers: { 'X-Wallet-Address': walletAddress },
-    }),
+    authHeaders(walletAddress).then((headers) => api.delete(`/orders/${id}`, { headers })),
+  cancelByClientId: (clientOrderId: string, walletAddress: string) =>
+    authHeaders(walletAddress).then((headers) =>
+      api.delete(`/orders/client/${encodeURIComponent(clientOrderId)}`, { headers })),
+  getUserOrders: (walletAddress: string, params?: PageParams) =>
+    authHeaders(walletAddress).then((headers) =>
+      api.get<Page<Order>>('/user/orders', { params, headers })),
+};
+
+export interface APIKey {
+  id: number;
+  key: string;
+  label: string;
+  scopes: string;
+  allowed_ips: string;
+  expires_at: string | null;
+  last_used_at: string | null;
+  created_at: string;
+}
+
+// API keys are managed with a Sign-In with Ethereum session
+export const apiKeyApi = {
+  list: (walletAddress: string) =>
+    authHeaders(walletAddress).then((headers) => api.get<APIKey[]>('/user/api-keys', { headers })),
+  create: (data: {
+    label?: string;
+    scopes: ('read' | 'trade' | 'cancel')[];
+    allowed_ips?: string[];
+    expires_at?: string;
+  }, walletAddress: string) =>
+    authHeaders(walletAddress).then((headers) =>
+      api.post<APIKey & { secret: string }>('/user/api-keys', data, { headers })),
+  revoke: (id: number, walletAddress: string) =>
+    authHeaders(walletAddress).then((headers) => api.delete(`/user/api-keys/${id}`, { headers })),
 };
 
 export default api;
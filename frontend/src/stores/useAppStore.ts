@@ -34,7 +34,7 @@ export const useAppStore = create<AppState>((set, get) => ({
     set({ isLoading: true, error: null });
     try {
       const res = await marketApi.list();
-      set({ markets: res.data, isLoading: false });
+      set({ markets: res.data.data, isLoading: false });
     } catch (err: any) {
       set({ error: err.message, isLoading: false });
     }
@@ -53,7 +53,7 @@ export const useAppStore = create<AppState>((set, get) => ({
   fetchUserOrders: async (address: string) => {
     try {
       const res = await orderApi.getUserOrders(address);
-      set({ userOrders: res.data });
+      set({ userOrders: res.data.data });
     } catch (err: any) {
       console.error('Failed to fetch orders:', err);
     }